@@ -0,0 +1,356 @@
+/*
+Copyright © 2025 Krane CLI menbiyagoral@gmail.com
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"krane/pkg/audit"
+	"krane/pkg/ecr"
+	"krane/pkg/k8s"
+	"krane/pkg/utils"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// AuditOptions holds flag values for the audit command.
+type AuditOptions struct {
+	AllNamespaces     bool
+	Namespace         string
+	Region            string
+	RepositoryPrefix  string
+	Format            string
+	AllowedRegistries []string
+	DisabledRules     []string
+	CheckECR          bool
+	FailOn            string
+	IncludeNamespaces []string
+	ExcludeNamespaces []string
+	IncludePatterns   []string
+	ExcludePatterns   []string
+}
+
+// Validate validates audit command options and returns error if invalid.
+func (opts *AuditOptions) Validate() error {
+	validFormats := map[string]bool{"table": true, "json": true, "yaml": true, "sarif": true}
+	if !validFormats[opts.Format] {
+		return fmt.Errorf("invalid format: %s (valid: table, json, yaml, sarif)", opts.Format)
+	}
+	validFailOn := map[string]bool{audit.LevelError: true, audit.LevelWarning: true, "none": true}
+	if !validFailOn[opts.FailOn] {
+		return fmt.Errorf("invalid fail-on: %s (valid: error, warning, none)", opts.FailOn)
+	}
+	known := map[string]bool{}
+	var ids []string
+	for _, r := range audit.Rules {
+		known[r.ID] = true
+		ids = append(ids, r.ID)
+	}
+	for _, id := range opts.DisabledRules {
+		if !known[strings.TrimSpace(id)] {
+			return fmt.Errorf("unknown rule: %s (valid: %s)", id, strings.Join(ids, ", "))
+		}
+	}
+	return nil
+}
+
+// newAuditCmd constructs the audit command with its own options.
+func newAuditCmd() *cobra.Command {
+	opts := &AuditOptions{Format: "table", FailOn: audit.LevelError}
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Audit cluster images against image policy rules",
+		Long: `Evaluate every discovered image against image policy rules and report
+violations together with the workloads that use the image.
+
+Rules:
+  latest-tag            image uses :latest or no tag
+  unpinned-digest       image is not pinned by digest
+  registry-not-allowed  registry is outside --allowed-registries (only when set)
+  deprecated-registry   image comes from k8s.gcr.io
+  inconsistent-tags     repository is used with different tags across namespaces
+  missing-in-ecr        image is not mirrored to ECR yet (only with --check-ecr)
+
+The command exits with a non-zero status when a violation at or above the
+--fail-on level is found.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Namespace = globalNamespace
+			opts.AllNamespaces = globalAllNamespaces
+			region, err := singleRegion()
+			if err != nil {
+				return err
+			}
+			opts.Region = region
+			if globalOutput != "" {
+				opts.Format = globalOutput
+			}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return runAudit(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&opts.AllowedRegistries, "allowed-registries", nil, "Registry hosts images may come from (exact by default; re:, glob:, prefix:, i: and ! supported)")
+	cmd.Flags().StringSliceVar(&opts.DisabledRules, "disable-rule", nil, "Rule IDs to skip")
+	cmd.Flags().StringVar(&opts.FailOn, "fail-on", audit.LevelError, "Exit non-zero on violations at or above this level (error, warning, none)")
+	cmd.Flags().BoolVar(&opts.CheckECR, "check-ecr", false, "Report images that have not been mirrored to ECR yet")
+	cmd.Flags().StringVar(&opts.RepositoryPrefix, "prefix", "krane", "ECR repository prefix/namespace (with --check-ecr)")
+	cmd.Flags().StringSliceVar(&opts.IncludeNamespaces, "include-namespaces", nil, "Only include these namespaces "+namespacePatternHelp)
+	cmd.Flags().StringSliceVar(&opts.ExcludeNamespaces, "exclude-namespaces", nil, "Exclude these namespaces "+namespacePatternHelp)
+	cmd.Flags().StringSliceVarP(&opts.IncludePatterns, "include", "i", nil, "Only include images matching these patterns "+imagePatternHelp)
+	cmd.Flags().StringSliceVarP(&opts.ExcludePatterns, "exclude", "e", nil, "Exclude images matching these patterns "+imagePatternHelp)
+
+	return cmd
+}
+
+// AuditReport represents the structure for JSON/YAML audit output.
+type AuditReport struct {
+	Findings []audit.Finding `json:"findings" yaml:"findings"`
+	Images   int             `json:"images" yaml:"images"`
+	Total    int             `json:"total" yaml:"total"`
+}
+
+// runAudit executes the audit command with the given options.
+func runAudit(ctx context.Context, opts *AuditOptions) error {
+	client, err := k8s.NewClient("")
+	if err != nil {
+		return fmt.Errorf("creating Kubernetes client: %w", err)
+	}
+
+	effectiveAllNamespaces := opts.AllNamespaces
+	if strings.TrimSpace(opts.Namespace) == "" {
+		effectiveAllNamespaces = true
+	}
+
+	infos, err := k8s.ListPodImagesWithSource(ctx, client, k8s.DiscoveryOptions{
+		AllNamespaces:     effectiveAllNamespaces,
+		Namespace:         opts.Namespace,
+		IncludeNamespaces: opts.IncludeNamespaces,
+		ExcludeNamespaces: opts.ExcludeNamespaces,
+		Logger:            logger,
+	})
+	if err != nil {
+		return fmt.Errorf("listing pod images: %w", err)
+	}
+
+	var images []string
+	for _, info := range infos {
+		images = append(images, info.Image)
+	}
+	images, err = utils.FilterImages(utils.RemoveDuplicates(images), opts.IncludePatterns, opts.ExcludePatterns)
+	if err != nil {
+		return fmt.Errorf("invalid include/exclude patterns: %w", err)
+	}
+	infos = filterImageInfos(infos, images)
+
+	auditOpts := audit.Options{
+		AllowedRegistries: opts.AllowedRegistries,
+		DisabledRules:     opts.DisabledRules,
+	}
+	if opts.CheckECR {
+		ecrClient, err := ecr.NewClient(ctx, awsOptions(opts.Region))
+		if err != nil {
+			return fmt.Errorf("creating ECR client: %w", err)
+		}
+		auditOpts.ExistsInECR = func(image string) (bool, error) {
+			targetImage, repoName, err := ecrClient.ConvertImageName(image, opts.RepositoryPrefix)
+			if err != nil {
+				return false, err
+			}
+			return ecrClient.ImageTagExists(ctx, repoName, targetImage[strings.LastIndex(targetImage, ":")+1:])
+		}
+	}
+
+	findings, err := audit.Evaluate(infos, auditOpts)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Format {
+	case "json", "yaml":
+		report := AuditReport{Findings: findings, Images: len(images), Total: len(findings)}
+		var data []byte
+		if opts.Format == "json" {
+			data, err = json.MarshalIndent(report, "", "  ")
+		} else {
+			data, err = yaml.Marshal(report)
+		}
+		if err != nil {
+			return fmt.Errorf("marshaling %s: %w", strings.ToUpper(opts.Format), err)
+		}
+		fmt.Println(string(data))
+	case "sarif":
+		data, err := json.MarshalIndent(newSARIFLog(findings), "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling SARIF: %w", err)
+		}
+		fmt.Println(string(data))
+	default:
+		printAuditTable(findings, len(images))
+	}
+
+	failing := 0
+	for _, f := range findings {
+		if opts.FailOn == audit.LevelWarning || (opts.FailOn == audit.LevelError && f.Level == audit.LevelError) {
+			failing++
+		}
+	}
+	if failing > 0 {
+		return fmt.Errorf("audit found %d violations at or above %s level", failing, opts.FailOn)
+	}
+	return nil
+}
+
+// filterImageInfos keeps only infos whose image is in allowedImages.
+func filterImageInfos(infos []k8s.ImageInfo, allowedImages []string) []k8s.ImageInfo {
+	allow := map[string]bool{}
+	for _, img := range allowedImages {
+		allow[img] = true
+	}
+	var out []k8s.ImageInfo
+	for _, info := range infos {
+		if allow[info.Image] {
+			out = append(out, info)
+		}
+	}
+	return out
+}
+
+// printAuditTable prints audit findings as a table.
+func printAuditTable(findings []audit.Finding, imageCount int) {
+	if len(findings) == 0 {
+		fmt.Printf("✅ No violations found in %d images\n", imageCount)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LEVEL\tRULE\tIMAGE\tSOURCES\tMESSAGE")
+	for _, f := range findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", f.Level, f.RuleID, f.Image, formatSources(f.Sources, 2), f.Message)
+	}
+	w.Flush()
+	fmt.Printf("\nTotal: %d violations in %d images\n", len(findings), imageCount)
+}
+
+// formatSources formats up to max sources as ns/Kind/name, followed by a count of the rest.
+func formatSources(sources []k8s.ImageInfo, max int) string {
+	seen := map[string]bool{}
+	var parts []string
+	for _, s := range sources {
+		key := fmt.Sprintf("%s/%s/%s", s.Namespace, s.SourceKind, s.SourceName)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		parts = append(parts, key)
+	}
+	if len(parts) > max {
+		return fmt.Sprintf("%s (+%d more)", strings.Join(parts[:max], ","), len(parts)-max)
+	}
+	return strings.Join(parts, ",")
+}
+
+// SARIF 2.1.0 structures, limited to the fields krane emits.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// newSARIFLog converts audit findings into a SARIF log with one location per source workload.
+func newSARIFLog(findings []audit.Finding) sarifLog {
+	driver := sarifDriver{Name: "krane", InformationURI: "https://github.com/enbiyagoral/krane-cli"}
+	for _, r := range audit.Rules {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   r.ID,
+			ShortDescription:     sarifMessage{Text: r.Description},
+			DefaultConfiguration: sarifConfiguration{Level: r.Level},
+		})
+	}
+
+	results := []sarifResult{}
+	for _, f := range findings {
+		seen := map[string]bool{}
+		var locations []sarifLocation
+		for _, s := range f.Sources {
+			fqn := fmt.Sprintf("%s/%s/%s", s.Namespace, s.SourceKind, s.SourceName)
+			if seen[fqn] {
+				continue
+			}
+			seen[fqn] = true
+			locations = append(locations, sarifLocation{LogicalLocations: []sarifLogicalLocation{{
+				Name:               s.SourceName,
+				FullyQualifiedName: fqn,
+				Kind:               "resource",
+			}}})
+		}
+		results = append(results, sarifResult{
+			RuleID:     f.RuleID,
+			Level:      f.Level,
+			Message:    sarifMessage{Text: fmt.Sprintf("%s: %s", f.Image, f.Message)},
+			Locations:  locations,
+			Properties: map[string]string{"image": f.Image},
+		})
+	}
+
+	return sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright © 2025 Krane CLI menbiyagoral@gmail.com
+*/
+package cmd
+
+import (
+	"sync"
+)
+
+// registryQueue holds the pending jobs of one source registry.
+type registryQueue struct {
+	host    string
+	limit   int // 0 means only the global --max-concurrent applies
+	pending []ImageJob
+	running int
+}
+
+// dispatcher hands out image jobs to workers, round-robin across source registries and
+// within each registry's concurrency limit, so a backlog on one registry does not hold
+// back jobs for the others.
+type dispatcher struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	queues    []*registryQueue
+	byJob     map[int]*registryQueue
+	next      int
+	remaining int
+	draining  bool
+}
+
+// newDispatcher queues jobs by source registry. limits maps a registry host to its
+// maximum number of concurrent jobs.
+func newDispatcher(jobs []ImageJob, limits map[string]int) *dispatcher {
+	d := &dispatcher{byJob: map[int]*registryQueue{}, remaining: len(jobs)}
+	d.cond = sync.NewCond(&d.mu)
+	byHost := map[string]*registryQueue{}
+	for _, job := range jobs {
+		q, ok := byHost[job.Registry]
+		if !ok {
+			q = &registryQueue{host: job.Registry, limit: limits[job.Registry]}
+			byHost[job.Registry] = q
+			d.queues = append(d.queues, q)
+		}
+		q.pending = append(q.pending, job)
+		d.byJob[job.Index] = q
+	}
+	return d
+}
+
+// Next blocks until a job may start and returns it, or returns false when no jobs remain.
+func (d *dispatcher) Next() (ImageJob, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for {
+		if d.remaining == 0 {
+			return ImageJob{}, false
+		}
+		for i := range d.queues {
+			idx := (d.next + i) % len(d.queues)
+			q := d.queues[idx]
+			if len(q.pending) == 0 || (!d.draining && q.limit > 0 && q.running >= q.limit) {
+				continue
+			}
+			job := q.pending[0]
+			q.pending = q.pending[1:]
+			q.running++
+			d.remaining--
+			d.next = idx + 1
+			return job, true
+		}
+		d.cond.Wait()
+	}
+}
+
+// Done releases the registry slot taken by job.
+func (d *dispatcher) Done(job ImageJob) {
+	d.mu.Lock()
+	d.byJob[job.Index].running--
+	d.mu.Unlock()
+	d.cond.Broadcast()
+}
+
+// Drain lifts the registry limits so the remaining jobs can be handed out and reported
+// as not started.
+func (d *dispatcher) Drain() {
+	d.mu.Lock()
+	d.draining = true
+	d.mu.Unlock()
+	d.cond.Broadcast()
+}
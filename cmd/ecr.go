@@ -0,0 +1,531 @@
+/*
+Copyright © 2025 Krane CLI menbiyagoral@gmail.com
+*/
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"krane/pkg/ecr"
+	"krane/pkg/k8s"
+	"krane/pkg/utils"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// ECRListOptions holds flag values for the ecr list command.
+type ECRListOptions struct {
+	Region           string
+	RepositoryPrefix string
+	Format           string
+}
+
+// Validate validates ecr list command options and returns error if invalid.
+func (opts *ECRListOptions) Validate() error {
+	validFormats := map[string]bool{"table": true, "json": true, "yaml": true}
+	if !validFormats[opts.Format] {
+		return fmt.Errorf("invalid format: %s (valid: table, json, yaml)", opts.Format)
+	}
+	return nil
+}
+
+// ECRPruneOptions holds flag values for the ecr prune command.
+type ECRPruneOptions struct {
+	Region             string
+	RepositoryPrefix   string
+	Contexts           []string
+	KeepLast           int
+	OlderThan          string
+	DeleteRepositories bool
+	DryRun             bool
+	Yes                bool
+
+	olderThan time.Duration
+}
+
+// Validate validates ecr prune command options and returns error if invalid.
+func (opts *ECRPruneOptions) Validate() error {
+	if opts.KeepLast < 0 {
+		return fmt.Errorf("keep-last must not be negative, got: %d", opts.KeepLast)
+	}
+	if strings.TrimSpace(opts.RepositoryPrefix) == "" {
+		return fmt.Errorf("prefix must not be empty")
+	}
+	d, err := utils.ParseAge(opts.OlderThan)
+	if err != nil {
+		return fmt.Errorf("invalid older-than: %w", err)
+	}
+	opts.olderThan = d
+	return nil
+}
+
+// newECRCmd constructs the ecr command grouping ECR inventory subcommands.
+func newECRCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ecr",
+		Short: "Inspect and clean up mirrored images in AWS ECR",
+		Long: `Inspect and clean up the ECR repositories created by krane push.
+
+All subcommands operate on repositories below the given --prefix.`,
+	}
+
+	cmd.AddCommand(newECRListCmd())
+	cmd.AddCommand(newECRPruneCmd())
+	return cmd
+}
+
+// newECRListCmd constructs the ecr list command with its own options.
+func newECRListCmd() *cobra.Command {
+	opts := &ECRListOptions{Format: "table"}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List mirrored repositories and images in ECR",
+		Long: `List all ECR repositories below the prefix together with their images,
+including tags, digests, sizes and push dates.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			region, err := singleRegion()
+			if err != nil {
+				return err
+			}
+			opts.Region = region
+			if globalOutput != "" {
+				opts.Format = globalOutput
+			}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return runECRList(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.RepositoryPrefix, "prefix", "krane", "ECR repository prefix/namespace")
+
+	return cmd
+}
+
+// newECRPruneCmd constructs the ecr prune command with its own options.
+func newECRPruneCmd() *cobra.Command {
+	opts := &ECRPruneOptions{DryRun: true}
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete mirrored images no longer used by any workload",
+		Long: `Delete images below the prefix that are not referenced by any pod in the
+selected clusters.
+
+Cluster images are mapped to ECR repositories and tags with the same naming
+rules used by krane push. All namespaces of every selected context are
+considered in use. The command runs as a dry run by default; pass
+--dry-run=false to delete, and confirm the prompt (or use --yes).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			region, err := singleRegion()
+			if err != nil {
+				return err
+			}
+			opts.Region = region
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return runECRPrune(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.RepositoryPrefix, "prefix", "krane", "ECR repository prefix/namespace")
+	cmd.Flags().StringSliceVar(&opts.Contexts, "context", nil, "Kubeconfig contexts whose workloads are considered in use (default: current context)")
+	cmd.Flags().IntVar(&opts.KeepLast, "keep-last", 0, "Always keep the N most recently pushed images in each repository")
+	cmd.Flags().StringVar(&opts.OlderThan, "older-than", "", "Only delete images pushed before this age (e.g. 72h, 30d, 2w)")
+	cmd.Flags().BoolVar(&opts.DeleteRepositories, "delete-repos", false, "Delete whole repositories when none of their images are in use")
+	cmd.Flags().BoolVarP(&opts.DryRun, "dry-run", "d", true, "Only show what would be deleted")
+	cmd.Flags().BoolVarP(&opts.Yes, "yes", "y", false, "Do not ask for confirmation before deleting")
+
+	return cmd
+}
+
+// RepositoryInventory groups the images of a single ECR repository.
+type RepositoryInventory struct {
+	ecr.Repository `yaml:",inline"`
+	Images         []ecr.Image `json:"images" yaml:"images"`
+}
+
+// ECRInventory represents the structure for JSON/YAML inventory output.
+type ECRInventory struct {
+	Repositories   []RepositoryInventory `json:"repositories" yaml:"repositories"`
+	TotalImages    int                   `json:"totalImages" yaml:"totalImages"`
+	TotalSizeBytes int64                 `json:"totalSizeBytes" yaml:"totalSizeBytes"`
+}
+
+// runECRList executes the ecr list command with the given options.
+func runECRList(ctx context.Context, opts *ECRListOptions) error {
+	ecrClient, err := ecr.NewClient(ctx, awsOptions(opts.Region))
+	if err != nil {
+		return fmt.Errorf("creating ECR client: %w", err)
+	}
+
+	inventory, err := collectECRInventory(ctx, ecrClient, opts.RepositoryPrefix)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Format {
+	case "json":
+		data, err := json.MarshalIndent(inventory, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	case "yaml":
+		data, err := yaml.Marshal(inventory)
+		if err != nil {
+			return fmt.Errorf("marshaling YAML: %w", err)
+		}
+		fmt.Println(string(data))
+	default:
+		printECRInventoryTable(inventory)
+	}
+	return nil
+}
+
+// collectECRInventory lists all repositories below prefix with their images, newest first.
+func collectECRInventory(ctx context.Context, ecrClient *ecr.Client, prefix string) (*ECRInventory, error) {
+	repos, err := ecrClient.ListRepositories(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("listing ECR repositories: %w", err)
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+
+	inventory := &ECRInventory{}
+	for _, repo := range repos {
+		images, err := ecrClient.ListImages(ctx, repo.Name)
+		if err != nil {
+			return nil, fmt.Errorf("listing ECR images: %w", err)
+		}
+		sort.Slice(images, func(i, j int) bool { return images[i].PushedAt.After(images[j].PushedAt) })
+		for _, img := range images {
+			inventory.TotalImages++
+			inventory.TotalSizeBytes += img.SizeBytes
+		}
+		inventory.Repositories = append(inventory.Repositories, RepositoryInventory{Repository: repo, Images: images})
+	}
+	return inventory, nil
+}
+
+// printECRInventoryTable prints the inventory as a table, one row per image.
+func printECRInventoryTable(inventory *ECRInventory) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tTAGS\tDIGEST\tSIZE\tPUSHED")
+	for _, repo := range inventory.Repositories {
+		if len(repo.Images) == 0 {
+			fmt.Fprintf(w, "%s\t<empty>\t\t\t\n", repo.Name)
+			continue
+		}
+		for _, img := range repo.Images {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				repo.Name, formatTags(img.Tags), shortDigest(img.Digest),
+				utils.FormatBytes(img.SizeBytes), img.PushedAt.Format(time.RFC3339))
+		}
+	}
+	w.Flush()
+	fmt.Printf("\nTotal: %d repositories, %d images, %s\n",
+		len(inventory.Repositories), inventory.TotalImages, utils.FormatBytes(inventory.TotalSizeBytes))
+}
+
+// formatTags joins image tags for display.
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return "<untagged>"
+	}
+	return strings.Join(tags, ",")
+}
+
+// shortDigest shortens a sha256 digest for table output.
+func shortDigest(digest string) string {
+	d := strings.TrimPrefix(digest, "sha256:")
+	if len(d) > 12 {
+		d = d[:12]
+	}
+	return d
+}
+
+// referenceSet holds the ECR identities of images used by cluster workloads.
+type referenceSet struct {
+	tags    map[string]map[string]bool // repository -> tag
+	digests map[string]bool
+}
+
+// isReferenced reports whether any tag or the digest of img is in use.
+func (r referenceSet) isReferenced(img ecr.Image) bool {
+	if r.digests[img.Digest] {
+		return true
+	}
+	for _, tag := range img.Tags {
+		if r.tags[img.Repository][tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// collectReferencedImages maps all pod images in the given contexts to ECR repositories and tags.
+func collectReferencedImages(ctx context.Context, ecrClient *ecr.Client, contexts []string, prefix string) (referenceSet, error) {
+	refs := referenceSet{tags: map[string]map[string]bool{}, digests: map[string]bool{}}
+	if len(contexts) == 0 {
+		contexts = []string{""}
+	}
+
+	for _, kubeContext := range contexts {
+		client, err := k8s.NewClientForContext("", kubeContext)
+		if err != nil {
+			return refs, fmt.Errorf("creating Kubernetes client: %w", err)
+		}
+		// Debug containers count as in use so their images are not pruned from under them
+		images, err := k8s.ListPodImagesFiltered(ctx, client, k8s.DiscoveryOptions{AllNamespaces: true, IncludeEphemeral: true, Logger: logger})
+		if err != nil {
+			return refs, fmt.Errorf("listing pod images: %w", err)
+		}
+
+		for _, image := range utils.RemoveDuplicates(images) {
+			if err := refs.add(ecrClient, image, prefix); err != nil {
+				logger.Warn("could not map image to ECR", "image", image, "error", err)
+			}
+		}
+	}
+	return refs, nil
+}
+
+// add records the ECR repository, tag and digest of a pod image. Images that already run
+// from ECR are recorded as they are; other images under their mirrored name below prefix.
+func (r referenceSet) add(ecrClient *ecr.Client, image, prefix string) error {
+	if at := strings.Index(image, "@sha256:"); at != -1 {
+		r.digests[image[at+1:]] = true
+	}
+	repoName, tag, ok := ecrClient.ECRRepository(image)
+	if !ok {
+		targetImage, mirrored, err := ecrClient.ConvertImageName(image, prefix)
+		if err != nil {
+			return err
+		}
+		repoName, tag = mirrored, targetImage[strings.LastIndex(targetImage, ":")+1:]
+	}
+	if tag == "" {
+		return nil
+	}
+	if r.tags[repoName] == nil {
+		r.tags[repoName] = map[string]bool{}
+	}
+	r.tags[repoName][tag] = true
+	return nil
+}
+
+// usesRepository reports whether any tag of repo or the digest of one of its images is in use.
+func (r referenceSet) usesRepository(repo string, images []ecr.Image) bool {
+	if len(r.tags[repo]) > 0 {
+		return true
+	}
+	for _, img := range images {
+		if r.digests[img.Digest] {
+			return true
+		}
+	}
+	return false
+}
+
+// prunePlan describes what would be deleted from a single repository.
+type prunePlan struct {
+	Repository       string
+	DeleteRepository bool
+	Images           []ecr.Image
+}
+
+// planPrune selects the images of a repository that may be deleted. Images must be sorted
+// newest first, and children maps each index digest to the manifests it lists. Platform
+// manifests of an index are kept with it and do not count towards --keep-last.
+func planPrune(repo string, images []ecr.Image, children map[string][]string, refs referenceSet, opts *ECRPruneOptions, now time.Time) prunePlan {
+	plan := prunePlan{Repository: repo}
+	isChild := map[string]bool{}
+	for _, digests := range children {
+		for _, d := range digests {
+			isChild[d] = true
+		}
+	}
+
+	kept := 0
+	keptChildren := map[string]bool{}
+	var orphans []ecr.Image
+	for _, img := range images {
+		if isChild[img.Digest] {
+			orphans = append(orphans, img)
+			continue
+		}
+		switch {
+		case kept < opts.KeepLast,
+			refs.isReferenced(img),
+			opts.olderThan > 0 && now.Sub(img.PushedAt) < opts.olderThan:
+			kept++
+			for _, d := range children[img.Digest] {
+				keptChildren[d] = true
+			}
+		default:
+			plan.Images = append(plan.Images, img)
+		}
+	}
+	for _, img := range orphans {
+		if keptChildren[img.Digest] || refs.isReferenced(img) {
+			kept++
+			continue
+		}
+		plan.Images = append(plan.Images, img)
+	}
+	// A repository is only deleted when nothing references it, not merely when no image was kept
+	if opts.DeleteRepositories && kept == 0 && !refs.usesRepository(repo, images) {
+		plan.DeleteRepository = true
+	}
+	return plan
+}
+
+// pruneFailure records an image or repository that could not be deleted.
+type pruneFailure struct {
+	Repository string
+	Digest     string
+	Reason     string
+}
+
+// runECRPrune executes the ecr prune command with the given options.
+func runECRPrune(ctx context.Context, opts *ECRPruneOptions) error {
+	ecrClient, err := ecr.NewClient(ctx, awsOptions(opts.Region))
+	if err != nil {
+		return fmt.Errorf("creating ECR client: %w", err)
+	}
+
+	refs, err := collectReferencedImages(ctx, ecrClient, opts.Contexts, opts.RepositoryPrefix)
+	if err != nil {
+		return err
+	}
+
+	inventory, err := collectECRInventory(ctx, ecrClient, opts.RepositoryPrefix)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var plans []prunePlan
+	imageCount := 0
+	var reclaimed int64
+	for _, repo := range inventory.Repositories {
+		var indexes []string
+		for _, img := range repo.Images {
+			if img.IsIndex() {
+				indexes = append(indexes, img.Digest)
+			}
+		}
+		children, err := ecrClient.IndexChildren(ctx, repo.Name, indexes)
+		if err != nil {
+			return err
+		}
+		plan := planPrune(repo.Name, repo.Images, children, refs, opts, now)
+		if len(plan.Images) == 0 && !plan.DeleteRepository {
+			continue
+		}
+		plans = append(plans, plan)
+		for _, img := range plan.Images {
+			imageCount++
+			reclaimed += img.SizeBytes
+		}
+	}
+
+	if len(plans) == 0 {
+		logger.Info("nothing to prune")
+		return nil
+	}
+
+	for _, plan := range plans {
+		if plan.DeleteRepository {
+			fmt.Printf("🗑️  %s (entire repository)\n", plan.Repository)
+		} else {
+			fmt.Printf("🗑️  %s\n", plan.Repository)
+		}
+		for _, img := range plan.Images {
+			fmt.Printf("   - %s %s %s pushed %s\n", shortDigest(img.Digest), formatTags(img.Tags),
+				utils.FormatBytes(img.SizeBytes), img.PushedAt.Format(time.RFC3339))
+		}
+	}
+	fmt.Printf("\n📊 %d images in %d repositories, %s reclaimable\n", imageCount, len(plans), utils.FormatBytes(reclaimed))
+
+	if opts.DryRun {
+		logger.Info("dry run: nothing deleted (use --dry-run=false to delete)")
+		return nil
+	}
+
+	if !opts.Yes && !confirm("Delete these images?") {
+		logger.Info("aborted")
+		return nil
+	}
+
+	var failures []pruneFailure
+	deleted := 0
+	for _, plan := range plans {
+		if plan.DeleteRepository {
+			if err := ecrClient.DeleteRepository(ctx, plan.Repository); err != nil {
+				failures = append(failures, pruneFailure{Repository: plan.Repository, Reason: err.Error()})
+				continue
+			}
+			deleted += len(plan.Images)
+			logger.Info("deleted repository", "repository", plan.Repository)
+			continue
+		}
+		// Indexes go first; ECR refuses to delete manifests an index still references
+		var indexes, manifests []string
+		for _, img := range plan.Images {
+			if img.IsIndex() {
+				indexes = append(indexes, img.Digest)
+			} else {
+				manifests = append(manifests, img.Digest)
+			}
+		}
+		count := 0
+		for _, digests := range [][]string{indexes, manifests} {
+			imageFailures, err := ecrClient.DeleteImages(ctx, plan.Repository, digests)
+			for _, f := range imageFailures {
+				failures = append(failures, pruneFailure{Repository: plan.Repository, Digest: f.Digest, Reason: f.Code + ": " + f.Reason})
+			}
+			if err != nil {
+				failures = append(failures, pruneFailure{Repository: plan.Repository, Reason: err.Error()})
+				break
+			}
+			count += len(digests) - len(imageFailures)
+		}
+		deleted += count
+		logger.Info("deleted images", "repository", plan.Repository, "count", count)
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+	fmt.Printf("\n⚠️  %d deletions failed:\n", len(failures))
+	for _, f := range failures {
+		if f.Digest != "" {
+			fmt.Printf("   - %s %s: %s\n", f.Repository, shortDigest(f.Digest), f.Reason)
+		} else {
+			fmt.Printf("   - %s: %s\n", f.Repository, f.Reason)
+		}
+	}
+	return fmt.Errorf("deleted %d of %d images, %d deletions failed", deleted, imageCount, len(failures))
+}
+
+// confirm asks a yes/no question on stdin and reports whether the answer was yes.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
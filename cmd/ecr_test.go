@@ -0,0 +1,124 @@
+/*
+Copyright © 2025 Krane CLI menbiyagoral@gmail.com
+*/
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"krane/pkg/ecr"
+)
+
+func TestReferenceSetAdd(t *testing.T) {
+	client := ecr.NewClientFromAPI(nil, "eu-west-1", "123456789012")
+
+	tests := []struct {
+		name     string
+		image    string
+		wantRepo string
+		wantTag  string
+	}{
+		{"upstream image", "nginx:1.2", "krane/nginx", "1.2"},
+		{"upstream image with registry", "registry.k8s.io/pause:3.10", "krane/pause", "3.10"},
+		{"mirrored copy in this registry", "123456789012.dkr.ecr.eu-west-1.amazonaws.com/krane/nginx:1.2", "krane/nginx", "1.2"},
+		{"mirrored copy without tag", "123456789012.dkr.ecr.eu-west-1.amazonaws.com/krane/nginx", "krane/nginx", "latest"},
+		{"other ECR registry", "210987654321.dkr.ecr.us-east-1.amazonaws.com/team/app:v3", "team/app", "v3"},
+		{"dual-stack ECR host", "123456789012.dkr-ecr.eu-west-1.on.aws/krane/redis:7", "krane/redis", "7"},
+		{"ECR pull-through path", "123456789012.dkr.ecr.eu-west-1.amazonaws.com/docker-hub/library/nginx:1.27", "docker-hub/library/nginx", "1.27"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs := referenceSet{tags: map[string]map[string]bool{}, digests: map[string]bool{}}
+			if err := refs.add(client, tt.image, "krane"); err != nil {
+				t.Fatalf("add(%q): %v", tt.image, err)
+			}
+			if !refs.tags[tt.wantRepo][tt.wantTag] {
+				t.Errorf("add(%q) recorded %v, want %s:%s", tt.image, refs.tags, tt.wantRepo, tt.wantTag)
+			}
+			if len(refs.tags) != 1 {
+				t.Errorf("add(%q) recorded %d repositories, want 1: %v", tt.image, len(refs.tags), refs.tags)
+			}
+		})
+	}
+
+	t.Run("mirrored copy by digest", func(t *testing.T) {
+		refs := referenceSet{tags: map[string]map[string]bool{}, digests: map[string]bool{}}
+		image := "123456789012.dkr.ecr.eu-west-1.amazonaws.com/krane/nginx@sha256:" + hexDigest("a")
+		if err := refs.add(client, image, "krane"); err != nil {
+			t.Fatalf("add(%q): %v", image, err)
+		}
+		if !refs.digests["sha256:"+hexDigest("a")] {
+			t.Errorf("digest not recorded: %v", refs.digests)
+		}
+		if len(refs.tags) != 0 {
+			t.Errorf("digest reference recorded tags: %v", refs.tags)
+		}
+	})
+}
+
+func TestPlanPruneKeepsImagesRunningFromECR(t *testing.T) {
+	client := ecr.NewClientFromAPI(nil, "eu-west-1", "123456789012")
+	refs := referenceSet{tags: map[string]map[string]bool{}, digests: map[string]bool{}}
+	for _, image := range []string{
+		"123456789012.dkr.ecr.eu-west-1.amazonaws.com/krane/nginx:1.2",
+		"123456789012.dkr.ecr.eu-west-1.amazonaws.com/krane/redis@sha256:" + hexDigest("b"),
+	} {
+		if err := refs.add(client, image, "krane"); err != nil {
+			t.Fatalf("add(%q): %v", image, err)
+		}
+	}
+
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	opts := &ECRPruneOptions{DeleteRepositories: true}
+
+	t.Run("referenced tag", func(t *testing.T) {
+		images := []ecr.Image{
+			{Repository: "krane/nginx", Digest: "sha256:" + hexDigest("c"), Tags: []string{"1.3"}, PushedAt: now.Add(-time.Hour)},
+			{Repository: "krane/nginx", Digest: "sha256:" + hexDigest("d"), Tags: []string{"1.2"}, PushedAt: now.Add(-2 * time.Hour)},
+		}
+		plan := planPrune("krane/nginx", images, nil, refs, opts, now)
+		if plan.DeleteRepository {
+			t.Error("repository with a running image would be deleted")
+		}
+		if len(plan.Images) != 1 || plan.Images[0].Tags[0] != "1.3" {
+			t.Errorf("planned deletions = %+v, want only the unreferenced 1.3", plan.Images)
+		}
+	})
+
+	t.Run("referenced digest", func(t *testing.T) {
+		images := []ecr.Image{
+			{Repository: "krane/redis", Digest: "sha256:" + hexDigest("b"), PushedAt: now.Add(-time.Hour)},
+		}
+		plan := planPrune("krane/redis", images, nil, refs, opts, now)
+		if plan.DeleteRepository || len(plan.Images) != 0 {
+			t.Errorf("plan = %+v, want the running image and its repository kept", plan)
+		}
+	})
+
+	t.Run("referenced tag that no longer exists", func(t *testing.T) {
+		images := []ecr.Image{
+			{Repository: "krane/nginx", Digest: "sha256:" + hexDigest("e"), Tags: []string{"1.1"}, PushedAt: now.Add(-time.Hour)},
+		}
+		plan := planPrune("krane/nginx", images, nil, refs, opts, now)
+		if plan.DeleteRepository {
+			t.Error("repository referenced by a workload would be deleted")
+		}
+	})
+
+	t.Run("unreferenced repository", func(t *testing.T) {
+		images := []ecr.Image{
+			{Repository: "krane/old", Digest: "sha256:" + hexDigest("f"), Tags: []string{"1.0"}, PushedAt: now.Add(-time.Hour)},
+		}
+		plan := planPrune("krane/old", images, nil, refs, opts, now)
+		if !plan.DeleteRepository || len(plan.Images) != 1 {
+			t.Errorf("plan = %+v, want the unused repository deleted", plan)
+		}
+	})
+}
+
+// hexDigest returns a sha256 hex string made of the repeated character c.
+func hexDigest(c string) string {
+	return strings.Repeat(c, 64)
+}
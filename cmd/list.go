@@ -10,12 +10,14 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"text/tabwriter"
 
 	"krane/pkg/k8s"
 	"krane/pkg/utils"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/kubernetes"
 )
 
 // ListOptions holds flag values for the list command.
@@ -28,13 +30,20 @@ type ListOptions struct {
 	IncludePatterns   []string
 	ExcludePatterns   []string
 	ShowSources       bool
+	IncludeEphemeral  bool
+	IncludeNodeImages bool
+	LabelSelector     string
+	NamespaceSelector string
+	FieldSelector     string
+	ExcludeTerminal   bool
+	PullStatus        bool
 }
 
 // Validate validates list command options and returns error if invalid.
 func (opts *ListOptions) Validate() error {
-	validFormats := map[string]bool{"table": true, "json": true, "yaml": true}
+	validFormats := map[string]bool{"table": true, "wide": true, "json": true, "yaml": true}
 	if !validFormats[opts.Format] {
-		return fmt.Errorf("invalid format: %s (valid: table, json, yaml)", opts.Format)
+		return fmt.Errorf("invalid format: %s (valid: table, wide, json, yaml)", opts.Format)
 	}
 	return nil
 }
@@ -48,7 +57,13 @@ func newListCmd() *cobra.Command {
 		Long: `List all container images running in Kubernetes pods.
     
 This command scans all pods (or specified namespace) and extracts
-the container images including init containers.`,
+the container images including init containers.
+
+With --pull-status, only containers currently failing to pull their image are
+shown, together with the waiting reason and message from the container status.
+
+With -o wide, each image is shown with the number of pods, containers,
+namespaces, workloads and nodes using it, its container types and pull policies.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Pull effective values from global persistent flags
 			opts.Namespace = globalNamespace
@@ -61,11 +76,18 @@ the container images including init containers.`,
 	}
 
 	// Global flags --namespace/-n, --all-namespaces/-A, --output/-o artık root seviyede
-	cmd.Flags().StringSliceVar(&opts.IncludeNamespaces, "include-namespaces", nil, "Only include these namespaces (prefix or regex; if regex compiles, it's used)")
-	cmd.Flags().StringSliceVar(&opts.ExcludeNamespaces, "exclude-namespaces", nil, "Exclude these namespaces (prefix or regex; if regex compiles, it's used)")
-	cmd.Flags().StringSliceVarP(&opts.IncludePatterns, "include", "i", nil, "Only include images matching these patterns (prefix or regex; if regex compiles, it's used)")
-	cmd.Flags().StringSliceVarP(&opts.ExcludePatterns, "exclude", "e", nil, "Exclude images matching these patterns (prefix or regex; if regex compiles, it's used)")
+	cmd.Flags().StringSliceVar(&opts.IncludeNamespaces, "include-namespaces", nil, "Only include these namespaces "+namespacePatternHelp)
+	cmd.Flags().StringSliceVar(&opts.ExcludeNamespaces, "exclude-namespaces", nil, "Exclude these namespaces "+namespacePatternHelp)
+	cmd.Flags().StringSliceVarP(&opts.IncludePatterns, "include", "i", nil, "Only include images matching these patterns "+imagePatternHelp)
+	cmd.Flags().StringSliceVarP(&opts.ExcludePatterns, "exclude", "e", nil, "Exclude images matching these patterns "+imagePatternHelp)
 	cmd.Flags().BoolVarP(&opts.ShowSources, "show-sources", "s", false, "Show source kind/name and namespace for each image")
+	cmd.Flags().StringVarP(&opts.LabelSelector, "selector", "l", "", "Only include pods matching this label selector (e.g. app=web,tier!=cache)")
+	cmd.Flags().StringVar(&opts.NamespaceSelector, "namespace-selector", "", "Only include namespaces whose labels match this selector (e.g. team=payments)")
+	cmd.Flags().StringVar(&opts.FieldSelector, "field-selector", "", "Only include pods matching this field selector (e.g. status.phase=Running)")
+	cmd.Flags().BoolVar(&opts.ExcludeTerminal, "exclude-terminal", false, "Exclude pods that have completed, failed or were evicted")
+	cmd.Flags().BoolVar(&opts.PullStatus, "pull-status", false, "Only show containers whose image cannot be pulled (ImagePullBackOff, ErrImagePull, ...)")
+	cmd.Flags().BoolVar(&opts.IncludeEphemeral, "include-ephemeral", false, "Also include images of ephemeral (debug) containers")
+	cmd.Flags().BoolVar(&opts.IncludeNodeImages, "include-node-images", false, "Also include images cached on nodes (source kind Node; cannot be combined with namespace or pod filters)")
 
 	return cmd
 }
@@ -91,11 +113,33 @@ func runList(ctx context.Context, opts *ListOptions) error {
 
 	// Warn if namespace filters are provided but not listing across all namespaces
 	if !effectiveAllNamespaces && (len(opts.IncludeNamespaces) > 0 || len(opts.ExcludeNamespaces) > 0) {
-		fmt.Fprintf(os.Stderr, "⚠️ include/exclude namespaces flags only apply when --all-namespaces is used; with --namespace they are ignored.\n")
+		logger.Warn("include/exclude namespaces flags only apply when --all-namespaces is used; with --namespace they are ignored")
+	}
+
+	discovery := k8s.DiscoveryOptions{
+		AllNamespaces:     effectiveAllNamespaces,
+		Namespace:         opts.Namespace,
+		IncludeNamespaces: opts.IncludeNamespaces,
+		ExcludeNamespaces: opts.ExcludeNamespaces,
+		IncludeEphemeral:  opts.IncludeEphemeral,
+		IncludeNodeImages: opts.IncludeNodeImages,
+		LabelSelector:     opts.LabelSelector,
+		NamespaceSelector: opts.NamespaceSelector,
+		FieldSelector:     opts.FieldSelector,
+		SkipAnnotated:     true,
+		ExcludeTerminal:   opts.ExcludeTerminal,
+		Logger:            logger,
+	}
+	if err := discovery.Validate(); err != nil {
+		return fmt.Errorf("--include-node-images: %w", err)
+	}
+
+	if opts.PullStatus {
+		return runPullStatus(ctx, client, discovery, opts)
 	}
 
-	if opts.ShowSources {
-		infos, err := k8s.ListPodImagesWithSource(client, effectiveAllNamespaces, opts.Namespace, opts.IncludeNamespaces, opts.ExcludeNamespaces)
+	if opts.ShowSources || opts.Format == "wide" {
+		infos, err := k8s.ListPodImagesWithSource(ctx, client, discovery)
 		if err != nil {
 			return fmt.Errorf("listing pod images: %w", err)
 		}
@@ -121,13 +165,21 @@ func runList(ctx context.Context, opts *ListOptions) error {
 				if sa.SourceKind != sb.SourceKind {
 					return sa.SourceKind < sb.SourceKind
 				}
-				return sa.SourceName < sb.SourceName
+				if sa.SourceName != sb.SourceName {
+					return sa.SourceName < sb.SourceName
+				}
+				if sa.PodName != sb.PodName {
+					return sa.PodName < sb.PodName
+				}
+				return sa.ContainerName < sb.ContainerName
 			})
 		}
 		// Print with sources depending on format
 		switch opts.Format {
 		case "table":
 			printTableGrouped(grouped)
+		case "wide":
+			printTableWide(grouped)
 		case "json":
 			printJSONGrouped(grouped)
 		case "yaml":
@@ -139,7 +191,7 @@ func runList(ctx context.Context, opts *ListOptions) error {
 	}
 
 	// List pod images with namespace filters
-	images, err := k8s.ListPodImagesFiltered(client, effectiveAllNamespaces, opts.Namespace, opts.IncludeNamespaces, opts.ExcludeNamespaces)
+	images, err := k8s.ListPodImagesFiltered(ctx, client, discovery)
 	if err != nil {
 		return fmt.Errorf("listing pod images: %w", err)
 	}
@@ -166,6 +218,72 @@ func runList(ctx context.Context, opts *ListOptions) error {
 	return nil
 }
 
+// runPullStatus lists containers that are waiting because their image cannot be pulled.
+func runPullStatus(ctx context.Context, client *kubernetes.Clientset, discovery k8s.DiscoveryOptions, opts *ListOptions) error {
+	infos, err := k8s.ListPodImagesWithSource(ctx, client, discovery)
+	if err != nil {
+		return fmt.Errorf("listing pod images: %w", err)
+	}
+
+	var failing []k8s.ImageInfo
+	var images []string
+	for _, info := range infos {
+		if k8s.IsImagePullFailure(info.WaitingReason) {
+			failing = append(failing, info)
+			images = append(images, info.Image)
+		}
+	}
+	filtered, err := utils.FilterImages(utils.RemoveDuplicates(images), opts.IncludePatterns, opts.ExcludePatterns)
+	if err != nil {
+		return fmt.Errorf("invalid include/exclude patterns: %w", err)
+	}
+	failing = filterImageInfos(failing, filtered)
+	sort.SliceStable(failing, func(i, j int) bool {
+		a, b := failing[i], failing[j]
+		if a.Image != b.Image {
+			return a.Image < b.Image
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.PodName < b.PodName
+	})
+
+	switch opts.Format {
+	case "json":
+		data, err := json.MarshalIndent(ImageUsageList{Usages: failing, Images: len(filtered), Total: len(failing)}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	case "yaml":
+		data, err := yaml.Marshal(ImageUsageList{Usages: failing, Images: len(filtered), Total: len(failing)})
+		if err != nil {
+			return fmt.Errorf("marshaling YAML: %w", err)
+		}
+		fmt.Println(string(data))
+	default:
+		printPullStatusTable(failing, len(filtered))
+	}
+	return nil
+}
+
+// printPullStatusTable prints one row per container failing to pull its image.
+func printPullStatusTable(failing []k8s.ImageInfo, imageCount int) {
+	if len(failing) == 0 {
+		fmt.Println("✅ No image pull failures")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tNAMESPACE\tPOD\tCONTAINER\tREASON\tMESSAGE")
+	for _, f := range failing {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", f.Image, f.Namespace, f.PodName, f.ContainerName, f.WaitingReason, f.WaitingMessage)
+	}
+	w.Flush()
+	fmt.Printf("\nTotal: %d containers failing to pull %d images\n", len(failing), imageCount)
+}
+
 // printTable prints images in a simple table format.
 func printTable(images []string) {
 	fmt.Println("CONTAINER IMAGES:")
@@ -177,8 +295,60 @@ func printTable(images []string) {
 }
 
 type GroupedImage struct {
-	Image   string          `json:"image" yaml:"image"`
-	Sources []k8s.ImageInfo `json:"sources" yaml:"sources"`
+	Image          string          `json:"image" yaml:"image"`
+	Pods           int             `json:"pods" yaml:"pods"`
+	Containers     int             `json:"containers" yaml:"containers"`
+	Namespaces     int             `json:"namespaces" yaml:"namespaces"`
+	Workloads      int             `json:"workloads" yaml:"workloads"`
+	Nodes          int             `json:"nodes" yaml:"nodes"`
+	ContainerTypes []string        `json:"containerTypes" yaml:"containerTypes"`
+	PullPolicies   []string        `json:"pullPolicies" yaml:"pullPolicies"`
+	Sources        []k8s.ImageInfo `json:"sources" yaml:"sources"`
+}
+
+// summarize fills the aggregate counts of a grouped image from its sources.
+func (g *GroupedImage) summarize() {
+	pods := map[string]bool{}
+	namespaces := map[string]bool{}
+	workloads := map[string]bool{}
+	nodes := map[string]bool{}
+	types := map[string]bool{}
+	policies := map[string]bool{}
+	containers := 0
+	for _, s := range g.Sources {
+		if s.NodeName != "" {
+			nodes[s.NodeName] = true
+		}
+		// Node image caches are not workloads and carry no container details
+		if s.SourceKind == k8s.SourceKindNode {
+			continue
+		}
+		containers++
+		pods[s.Namespace+"/"+s.PodName] = true
+		namespaces[s.Namespace] = true
+		workloads[s.Namespace+"/"+s.SourceKind+"/"+s.SourceName] = true
+		types[s.ContainerType] = true
+		if s.PullPolicy != "" {
+			policies[s.PullPolicy] = true
+		}
+	}
+	g.Pods = len(pods)
+	g.Containers = containers
+	g.Namespaces = len(namespaces)
+	g.Workloads = len(workloads)
+	g.Nodes = len(nodes)
+	g.ContainerTypes = sortedKeys(types)
+	g.PullPolicies = sortedKeys(policies)
+}
+
+// sortedKeys returns the keys of a set in sorted order.
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 // groupSourcesByImage groups image source information by image name.
@@ -196,7 +366,9 @@ func groupSourcesByImage(infos []k8s.ImageInfo, allowedImages []string) []Groupe
 	}
 	var out []GroupedImage
 	for image, srcs := range m {
-		out = append(out, GroupedImage{Image: image, Sources: srcs})
+		g := GroupedImage{Image: image, Sources: srcs}
+		g.summarize()
+		out = append(out, g)
 	}
 	return out
 }
@@ -223,6 +395,19 @@ func printTableGrouped(grouped []GroupedImage) {
 	fmt.Printf("\nTotal: %d unique images\n", len(grouped))
 }
 
+// printTableWide prints one row per image with aggregate usage columns.
+func printTableWide(grouped []GroupedImage) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tPODS\tCONTAINERS\tNAMESPACES\tWORKLOADS\tNODES\tTYPES\tPULL POLICY")
+	for _, g := range grouped {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%s\t%s\n",
+			g.Image, g.Pods, g.Containers, g.Namespaces, g.Workloads, g.Nodes,
+			strings.Join(g.ContainerTypes, ","), strings.Join(g.PullPolicies, ","))
+	}
+	w.Flush()
+	fmt.Printf("\nTotal: %d unique images\n", len(grouped))
+}
+
 // ImageList represents the structure for JSON output.
 type ImageList struct {
 	Images []string `json:"images"`
@@ -238,7 +423,7 @@ func printJSON(images []string) {
 
 	jsonData, err := json.MarshalIndent(imageList, "", "  ")
 	if err != nil {
-		fmt.Printf("Error marshaling JSON: %v\n", err)
+		logger.Error("marshaling JSON", "error", err)
 		return
 	}
 
@@ -255,7 +440,7 @@ func printJSONGrouped(grouped []GroupedImage) {
 	payload := GroupedImageList{Images: grouped, Total: len(grouped)}
 	data, err := json.MarshalIndent(payload, "", "  ")
 	if err != nil {
-		fmt.Printf("Error marshaling JSON: %v\n", err)
+		logger.Error("marshaling JSON", "error", err)
 		return
 	}
 	fmt.Println(string(data))
@@ -270,7 +455,7 @@ func printYAML(images []string) {
 
 	yamlData, err := yaml.Marshal(imageList)
 	if err != nil {
-		fmt.Printf("Error marshaling YAML: %v\n", err)
+		logger.Error("marshaling YAML", "error", err)
 		return
 	}
 
@@ -282,7 +467,7 @@ func printYAMLGrouped(grouped []GroupedImage) {
 	payload := GroupedImageList{Images: grouped, Total: len(grouped)}
 	data, err := yaml.Marshal(payload)
 	if err != nil {
-		fmt.Printf("Error marshaling YAML: %v\n", err)
+		logger.Error("marshaling YAML", "error", err)
 		return
 	}
 	fmt.Println(string(data))
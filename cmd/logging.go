@@ -0,0 +1,93 @@
+/*
+Copyright © 2025 Krane CLI menbiyagoral@gmail.com
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// levelTrace is below debug and enabled with --verbosity 2.
+const levelTrace = slog.LevelDebug - 4
+
+// logger is the command logger, configured from the global logging flags before each command runs.
+var logger = slog.New(slog.NewTextHandler(console, nil))
+
+// console is the stderr writer shared by the logger and the progress bar.
+var console = &consoleWriter{out: os.Stderr}
+
+// consoleWriter serializes writes to a terminal and keeps an optional status line
+// (the progress bar) below the log output.
+type consoleWriter struct {
+	mu     sync.Mutex
+	out    io.Writer
+	status string
+}
+
+// Write writes p above the status line.
+func (c *consoleWriter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.status != "" {
+		fmt.Fprint(c.out, "\r\033[K")
+	}
+	n, err := c.out.Write(p)
+	if c.status != "" {
+		fmt.Fprint(c.out, c.status)
+	}
+	return n, err
+}
+
+// SetStatus replaces the status line.
+func (c *consoleWriter) SetStatus(s string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprint(c.out, "\r\033[K"+s)
+	c.status = s
+}
+
+// ClearStatus removes the status line.
+func (c *consoleWriter) ClearStatus() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.status != "" {
+		fmt.Fprint(c.out, "\r\033[K")
+		c.status = ""
+	}
+}
+
+// newLogger creates a logger writing to the console in the given format.
+// Verbosity 0 logs info and above, 1 adds debug and 2 or more adds trace; quiet logs errors only.
+func newLogger(format string, verbosity int, quiet bool) (*slog.Logger, error) {
+	level := slog.LevelInfo
+	switch {
+	case quiet:
+		level = slog.LevelError
+	case verbosity >= 2:
+		level = levelTrace
+	case verbosity == 1:
+		level = slog.LevelDebug
+	}
+	handlerOpts := &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey && a.Value.Any() == levelTrace {
+				a.Value = slog.StringValue("TRACE")
+			}
+			return a
+		},
+	}
+
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(console, handlerOpts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(console, handlerOpts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format: %s (valid: text, json)", format)
+	}
+}
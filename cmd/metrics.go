@@ -0,0 +1,122 @@
+/*
+Copyright © 2025 Krane CLI menbiyagoral@gmail.com
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"krane/pkg/k8s"
+	"krane/pkg/metrics"
+	"krane/pkg/transfer"
+
+	"github.com/aws/smithy-go"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// kraneMetrics collects the metrics of the current run; they are only exported when
+// --metrics-addr, --metrics-file or --pushgateway is set.
+var kraneMetrics = metrics.New()
+
+// pushgatewayTimeout bounds the final push to the Pushgateway.
+const pushgatewayTimeout = 10 * time.Second
+
+func init() {
+	k8s.WrapTransport = instrument(metrics.ServiceKubernetes)
+}
+
+// registryTransport returns the transport for container registry requests, with the
+// connection settings of registries applied.
+func registryTransport(registries transfer.Registries) (http.RoundTripper, error) {
+	rt, err := transfer.NewRegistryTransport(registries)
+	if err != nil {
+		return nil, err
+	}
+	return instrument(metrics.ServiceRegistry)(rt), nil
+}
+
+// serveMetrics starts the /metrics endpoint when --metrics-addr is set.
+func serveMetrics(ctx context.Context) error {
+	if globalMetricsAddr == "" {
+		return nil
+	}
+	if err := kraneMetrics.Serve(ctx, globalMetricsAddr); err != nil {
+		return err
+	}
+	logger.Info("serving metrics", "address", globalMetricsAddr, "path", "/metrics")
+	return nil
+}
+
+// exportMetrics writes the textfile and pushes to the Pushgateway when configured.
+// It runs after the command, whether it succeeded or not.
+func exportMetrics() {
+	if globalMetricsFile != "" {
+		if err := kraneMetrics.WriteTextfile(globalMetricsFile); err != nil {
+			logger.Error("failed to write metrics", "error", err)
+		}
+	}
+	if globalPushgateway != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), pushgatewayTimeout)
+		defer cancel()
+		if err := kraneMetrics.Push(ctx, globalPushgateway, globalPushgatewayJob); err != nil {
+			logger.Error("failed to push metrics", "error", err)
+		}
+	}
+}
+
+// recordJobResult updates the image and target metrics for a finished push job. An image
+// pushed to some targets only counts as copied and as failed.
+func recordJobResult(result JobResult) {
+	for _, t := range result.Targets {
+		if t.Status != "" {
+			kraneMetrics.Targets.WithLabelValues(t.Registry, t.Status).Inc()
+		}
+	}
+	switch result.Status() {
+	case jobStatusPushed:
+		kraneMetrics.ImagesCopied.Inc()
+		kraneMetrics.CopyDuration.Observe(result.Stats.Elapsed.Seconds())
+	case jobStatusPartial:
+		kraneMetrics.ImagesCopied.Inc()
+		kraneMetrics.CopyDuration.Observe(result.Stats.Elapsed.Seconds())
+		kraneMetrics.ImagesFailed.WithLabelValues(failureReason(result)).Inc()
+	case jobStatusCached, jobStatusRewritten:
+		// Served by ECR pull-through; counted per target only
+	case jobStatusSkipped:
+		kraneMetrics.ImagesSkipped.Inc()
+	default:
+		kraneMetrics.ImagesFailed.WithLabelValues(failureReason(result)).Inc()
+	}
+	kraneMetrics.BytesTransferred.Add(float64(result.Stats.BytesDone))
+}
+
+// failureReason classifies why a job did not complete for the reason label.
+func failureReason(result JobResult) string {
+	if result.Cancelled {
+		return "cancelled"
+	}
+	err := result.Error
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	var terr *transport.Error
+	if errors.As(err, &terr) {
+		switch terr.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return "auth"
+		case http.StatusNotFound:
+			return "not_found"
+		case http.StatusTooManyRequests:
+			return "rate_limited"
+		}
+		return "registry"
+	}
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return "aws"
+	}
+	return "other"
+}
@@ -0,0 +1,132 @@
+/*
+Copyright © 2025 Krane CLI menbiyagoral@gmail.com
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"krane/pkg/tracing"
+	"krane/pkg/transfer"
+	"krane/pkg/utils"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// MirrorTagsOptions holds flag values for the mirror-tags command.
+type MirrorTagsOptions struct {
+	PushOptions
+	Repositories []string
+	Tags         transfer.TagFilter
+}
+
+// ValidateWithCmd validates mirror-tags options with access to cobra command for flag checking.
+func (opts *MirrorTagsOptions) ValidateWithCmd(cmd *cobra.Command) error {
+	if opts.Platform == "auto" {
+		return fmt.Errorf("--platform auto needs a cluster and is not supported by mirror-tags")
+	}
+	for _, repo := range opts.Repositories {
+		if _, tag := splitImageTag(repo); tag != "" {
+			return fmt.Errorf("expected a repository without tag or digest, got: %s", repo)
+		}
+	}
+	if err := opts.Tags.Validate(); err != nil {
+		return err
+	}
+	return opts.PushOptions.ValidateWithCmd(cmd)
+}
+
+// newMirrorTagsCmd constructs the mirror-tags command with its own options.
+func newMirrorTagsCmd() *cobra.Command {
+	opts := &MirrorTagsOptions{PushOptions: PushOptions{Mode: "copy", Progress: progressAuto}}
+	cmd := &cobra.Command{
+		Use:   "mirror-tags <repository>...",
+		Short: "Mirror the tags of a repository that match a constraint to AWS ECR",
+		Long: `Mirror tags of source repositories to AWS ECR, independently of what runs in the
+cluster, e.g. to keep previous versions available for rollbacks.
+
+Tags are listed from the source registry and selected with --semver, --regex, --last and
+--max-age; all filters that are set must match. Versions are ordered semantically, other
+tags by name. Images are named and skipped (--skip-existing) exactly as by push.
+
+Examples:
+  krane mirror-tags nginx --semver ">=1.26 <2" --last 5 -r eu-west-1
+  krane mirror-tags ghcr.io/org/app --regex '^v[0-9.]+$' --max-age 2160h -S`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Regions = utils.RemoveDuplicates(globalRegions)
+			opts.Repositories = args
+			if err := opts.ValidateWithCmd(cmd); err != nil {
+				return err
+			}
+			ctx, cancel := deadlineContext(cmd.Context(), opts.Deadline)
+			defer cancel()
+			return runMirrorTags(ctx, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Tags.Semver, "semver", "", "Select tags by semver constraint (e.g. \">=1.20 <2\"); tags that are not versions are skipped")
+	cmd.Flags().StringVar(&opts.Tags.Regex, "regex", "", "Select tags matching this regular expression")
+	cmd.Flags().IntVar(&opts.Tags.Last, "last", 0, "Keep only the N newest selected tags (0 = all)")
+	cmd.Flags().DurationVar(&opts.Tags.MaxAge, "max-age", 0, "Skip tags whose image is older than this (e.g. 720h)")
+	addTransferFlags(cmd, &opts.PushOptions)
+	addCopyFlags(cmd, &opts.PushOptions)
+
+	return cmd
+}
+
+// runMirrorTags lists and selects the tags of each repository and mirrors them to ECR.
+func runMirrorTags(ctx context.Context, opts *MirrorTagsOptions) error {
+	if err := opts.setupTransfer(); err != nil {
+		return err
+	}
+
+	phaseCtx, span := tracing.Start(ctx, "create ECR clients")
+	destinations, err := newDestinations(phaseCtx, &opts.PushOptions)
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+
+	phaseCtx, span = tracing.Start(ctx, "select tags")
+	images, err := selectRepositoryTags(phaseCtx, opts)
+	span.SetAttributes(attribute.Int("krane.images", len(images)))
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+	kraneMetrics.ImagesDiscovered.Add(float64(len(images)))
+	if len(images) == 0 {
+		logger.Info("no tags selected")
+		return nil
+	}
+
+	return pushImages(ctx, destinations, images, &opts.PushOptions)
+}
+
+// selectRepositoryTags returns the selected tags of every repository as image references.
+func selectRepositoryTags(ctx context.Context, opts *MirrorTagsOptions) ([]string, error) {
+	sourceOpts := transfer.Options{
+		Platforms:  opts.platforms,
+		Transport:  opts.transport,
+		Registries: opts.registries,
+	}
+	var images []string
+	for _, repo := range opts.Repositories {
+		tags, err := transfer.ListTags(ctx, repo, sourceOpts)
+		if err != nil {
+			return nil, err
+		}
+		selected, err := transfer.SelectTags(ctx, repo, tags, opts.Tags, sourceOpts)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("selected tags", "repository", repo, "tags", len(selected), "of", len(tags))
+		for _, tag := range selected {
+			images = append(images, repo+":"+tag)
+		}
+	}
+	return utils.RemoveDuplicates(images), nil
+}
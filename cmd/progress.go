@@ -0,0 +1,213 @@
+/*
+Copyright © 2025 Krane CLI menbiyagoral@gmail.com
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"krane/pkg/transfer"
+	"krane/pkg/utils"
+
+	"golang.org/x/term"
+)
+
+// Progress display modes.
+const (
+	progressAuto  = "auto"
+	progressBar   = "bar"
+	progressPlain = "plain"
+	progressNone  = "none"
+)
+
+// barRefresh is how often the TTY progress bar is redrawn.
+const barRefresh = 500 * time.Millisecond
+
+// jobProgress is the progress of one running image job.
+type jobProgress struct {
+	job      ImageJob
+	progress *transfer.Progress
+}
+
+// progressReporter renders aggregate push progress as a status line below the logs on a
+// TTY or as periodic log records otherwise, and keeps totals for the summary.
+type progressReporter struct {
+	mu       sync.Mutex
+	mode     string
+	interval time.Duration
+	start    time.Time
+	images   int
+	finished int
+	running  map[int]*jobProgress
+	done     transfer.ProgressSnapshot // totals of finished jobs
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// newProgressReporter creates a reporter for the given number of images. Mode auto
+// selects bar when stderr is a terminal and plain otherwise.
+func newProgressReporter(mode string, interval time.Duration, images int) *progressReporter {
+	if mode == progressAuto {
+		mode = progressPlain
+		if term.IsTerminal(int(os.Stderr.Fd())) {
+			mode = progressBar
+		}
+	}
+	return &progressReporter{
+		mode:     mode,
+		interval: interval,
+		start:    time.Now(),
+		images:   images,
+		running:  map[int]*jobProgress{},
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// Run renders progress until Stop is called.
+func (r *progressReporter) Run() {
+	defer close(r.stopped)
+	if r.mode == progressNone {
+		<-r.stop
+		return
+	}
+
+	interval := r.interval
+	if r.mode == progressBar {
+		interval = barRefresh
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			console.ClearStatus()
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			if r.mode == progressBar {
+				r.drawBar()
+			} else {
+				r.logProgress()
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// Stop stops rendering and waits for the renderer to exit.
+func (r *progressReporter) Stop() {
+	close(r.stop)
+	<-r.stopped
+}
+
+// Begin registers a running job and returns the Progress to pass to the transfer.
+func (r *progressReporter) Begin(job ImageJob) *transfer.Progress {
+	p := &transfer.Progress{}
+	r.mu.Lock()
+	r.running[job.Index] = &jobProgress{job: job, progress: p}
+	r.mu.Unlock()
+	return p
+}
+
+// End marks a job as finished, adds its counters to the totals and returns them.
+func (r *progressReporter) End(job ImageJob) transfer.ProgressSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var s transfer.ProgressSnapshot
+	if jp, ok := r.running[job.Index]; ok {
+		s = jp.progress.Snapshot()
+		delete(r.running, job.Index)
+	}
+	r.finished++
+	r.done.LayersDone += s.LayersDone
+	r.done.LayersTotal += s.LayersTotal
+	r.done.BytesDone += s.BytesDone
+	r.done.BytesTotal += s.BytesTotal
+	return s
+}
+
+// Totals returns the counters of all jobs, finished or running, and the elapsed time.
+func (r *progressReporter) Totals() transfer.ProgressSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.totalsLocked()
+}
+
+// totalsLocked sums finished and running jobs. The caller must hold r.mu.
+func (r *progressReporter) totalsLocked() transfer.ProgressSnapshot {
+	t := r.done
+	for _, jp := range r.running {
+		s := jp.progress.Snapshot()
+		t.LayersDone += s.LayersDone
+		t.LayersTotal += s.LayersTotal
+		t.BytesDone += s.BytesDone
+		t.BytesTotal += s.BytesTotal
+	}
+	t.Elapsed = time.Since(r.start)
+	return t
+}
+
+// drawBar redraws the aggregate progress bar in place. The caller must hold r.mu.
+func (r *progressReporter) drawBar() {
+	t := r.totalsLocked()
+	const width = 24
+	filled := 0
+	if t.BytesTotal > 0 {
+		filled = int(float64(width) * float64(t.BytesDone) / float64(t.BytesTotal))
+	}
+	if filled > width {
+		filled = width
+	}
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+	eta := ""
+	if d, ok := estimateETA(t); ok {
+		eta = " · ETA " + d.String()
+	}
+	console.SetStatus(fmt.Sprintf("⏳ %s %d/%d images · %d/%d layers · %s/%s · %s/s%s",
+		bar, r.finished, r.images, t.LayersDone, t.LayersTotal,
+		utils.FormatBytes(t.BytesDone), utils.FormatBytes(t.BytesTotal),
+		utils.FormatBytes(int64(t.Rate())), eta))
+}
+
+// logProgress logs one record per running job and an aggregate record. The caller must hold r.mu.
+func (r *progressReporter) logProgress() {
+	if len(r.running) == 0 {
+		return
+	}
+	indexes := make([]int, 0, len(r.running))
+	for i := range r.running {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	for _, i := range indexes {
+		jp := r.running[i]
+		s := jp.progress.Snapshot()
+		logger.Info("image progress", "index", jp.job.Index, "total", jp.job.Total, "image", jp.job.Image,
+			"layersDone", s.LayersDone, "layersTotal", s.LayersTotal,
+			"bytesDone", s.BytesDone, "bytesTotal", s.BytesTotal, "bytesPerSecond", int64(s.Rate()))
+	}
+	t := r.totalsLocked()
+	attrs := []any{"imagesDone", r.finished, "images", r.images,
+		"bytesDone", t.BytesDone, "bytesTotal", t.BytesTotal, "bytesPerSecond", int64(t.Rate())}
+	if d, ok := estimateETA(t); ok {
+		attrs = append(attrs, "eta", d)
+	}
+	logger.Info("push progress", attrs...)
+}
+
+// estimateETA estimates the remaining time from the known byte totals. Totals grow as
+// jobs start, so the estimate only covers work discovered so far.
+func estimateETA(t transfer.ProgressSnapshot) (time.Duration, bool) {
+	rate := t.Rate()
+	if rate <= 0 || t.BytesTotal <= t.BytesDone {
+		return 0, false
+	}
+	eta := time.Duration(float64(t.BytesTotal-t.BytesDone) / rate * float64(time.Second))
+	return eta.Round(time.Second), true
+}
@@ -5,22 +5,37 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"krane/pkg/ecr"
 	"krane/pkg/k8s"
+	"krane/pkg/tracing"
 	"krane/pkg/transfer"
 	"krane/pkg/utils"
 
+	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/kubernetes"
 )
 
 // PushOptions holds flag values for the push command.
 type PushOptions struct {
 	AllNamespaces     bool
-	Region            string
+	Regions           []string
+	TargetAccounts    []string
+	TargetRoleName    string
+	TargetRoleARNs    []string
 	RepositoryPrefix  string
 	Namespace         string
 	DryRun            bool
@@ -31,6 +46,45 @@ type PushOptions struct {
 	IncludePatterns   []string
 	ExcludePatterns   []string
 	MaxConcurrent     int
+	IncludeEphemeral  bool
+	IncludeNodeImages bool
+	LabelSelector     string
+	NamespaceSelector string
+	FieldSelector     string
+	ExcludeTerminal   bool
+	Mode              string
+	PTCCredentials    map[string]string
+	Warm              bool
+	Progress          string
+	ProgressInterval  time.Duration
+	Timeout           time.Duration
+	Deadline          time.Duration
+	GracePeriod       time.Duration
+	Report            string
+
+	RegistryConcurrency map[string]int
+	RegistryRates       map[string]string
+	UsePullSecrets      bool
+	CacheDir            string
+	CacheSize           string
+	Bandwidth           string
+	UploadBandwidth     string
+	DownloadBandwidth   string
+
+	WithSiblingTags bool
+	SiblingTags     transfer.TagFilter
+
+	platforms       []v1.Platform
+	registryLimits  map[string]int           // by canonical registry host
+	rateLimiters    map[string]*rate.Limiter // by canonical registry host
+	transport       http.RoundTripper
+	registries      transfer.Registries
+	sourceKeychains map[string]authn.Keychain // by image, from imagePullSecrets
+	cacheSize       int64
+	bandwidth       transfer.BandwidthLimits
+	destHosts       map[string]bool // registry hosts of the destinations, set before transfers start
+	blobCache       *transfer.BlobCache
+	mounts          *transfer.MountIndex
 }
 
 // Validate validates push command options and returns error if invalid.
@@ -38,9 +92,80 @@ func (opts *PushOptions) Validate() error {
 	if opts.MaxConcurrent < 1 || opts.MaxConcurrent > 50 {
 		return fmt.Errorf("max-concurrent must be between 1 and 50, got: %d", opts.MaxConcurrent)
 	}
+	for _, account := range opts.TargetAccounts {
+		if !isValidAccountID(account) {
+			return fmt.Errorf("invalid target account, expected 12 digits: %s", account)
+		}
+	}
+	if opts.Mode != "copy" && opts.Mode != "pull-through" {
+		return fmt.Errorf("invalid mode: %s (valid: copy, pull-through)", opts.Mode)
+	}
+	for name := range opts.PTCCredentials {
+		if !isPullThroughUpstream(name) {
+			return fmt.Errorf("unknown pull-through upstream in --ptc-credential: %s", name)
+		}
+	}
+	switch opts.Progress {
+	case progressAuto, progressBar, progressPlain, progressNone:
+	default:
+		return fmt.Errorf("invalid progress mode: %s (valid: auto, bar, plain, none)", opts.Progress)
+	}
+	if opts.ProgressInterval <= 0 {
+		return fmt.Errorf("progress-interval must be positive, got: %s", opts.ProgressInterval)
+	}
+	if opts.Timeout < 0 || opts.Deadline < 0 || opts.GracePeriod < 0 {
+		return fmt.Errorf("timeout, deadline and grace-period must not be negative")
+	}
+	if opts.Warm && opts.Mode != "pull-through" {
+		return fmt.Errorf("--warm requires --mode pull-through")
+	}
+	if len(opts.TargetAccounts) > 0 && opts.TargetRoleName == "" {
+		return fmt.Errorf("target-role-name is required with --target-account")
+	}
+	if opts.WithSiblingTags {
+		if err := opts.SiblingTags.Validate(); err != nil {
+			return fmt.Errorf("sibling tags: %w", err)
+		}
+	}
 	return nil
 }
 
+// isPullThroughUpstream reports whether name is a supported pull-through upstream.
+func isPullThroughUpstream(name string) bool {
+	for _, u := range ecr.PullThroughUpstreams {
+		if u.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidAccountID reports whether s is a 12-digit AWS account ID.
+func isValidAccountID(s string) bool {
+	if len(s) != 12 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// targetRoleARNs returns the roles to assume for each destination account.
+// An empty string stands for the caller's own account.
+func (opts *PushOptions) targetRoleARNs() []string {
+	if len(opts.TargetAccounts) == 0 && len(opts.TargetRoleARNs) == 0 {
+		return []string{""}
+	}
+	roles := append([]string{}, opts.TargetRoleARNs...)
+	for _, account := range opts.TargetAccounts {
+		roles = append(roles, fmt.Sprintf("arn:aws:iam::%s:role/%s", account, opts.TargetRoleName))
+	}
+	return utils.RemoveDuplicates(roles)
+}
+
 // ValidateWithCmd validates options with access to cobra command for flag checking.
 func (opts *PushOptions) ValidateWithCmd(cmd *cobra.Command) error {
 	if err := opts.Validate(); err != nil {
@@ -52,34 +177,173 @@ func (opts *PushOptions) ValidateWithCmd(cmd *cobra.Command) error {
 		return fmt.Errorf("platform flag provided but value is empty")
 	}
 
-	// If platform is provided, validate format
-	if opts.Platform != "" && !isValidPlatform(opts.Platform) {
-		return fmt.Errorf("invalid platform format, expected os/arch: %s", opts.Platform)
+	// If platforms are provided, validate format (auto is resolved from the nodes later)
+	if opts.Platform != "" && opts.Platform != "auto" {
+		platforms, err := transfer.ParsePlatforms(opts.Platform)
+		if err != nil {
+			return err
+		}
+		opts.platforms = platforms
+	}
+
+	size, err := utils.ParseBytes(opts.CacheSize)
+	if err != nil {
+		return fmt.Errorf("invalid cache-size: %w", err)
+	}
+	opts.cacheSize = size
+
+	for _, bw := range []struct {
+		flag  string
+		spec  string
+		bytes *int64
+	}{
+		{"bandwidth", opts.Bandwidth, &opts.bandwidth.Total},
+		{"upload-bandwidth", opts.UploadBandwidth, &opts.bandwidth.Upload},
+		{"download-bandwidth", opts.DownloadBandwidth, &opts.bandwidth.Download},
+	} {
+		n, err := transfer.ParseBandwidth(bw.spec)
+		if err != nil {
+			return fmt.Errorf("%s: %w", bw.flag, err)
+		}
+		*bw.bytes = n
+	}
+
+	opts.registryLimits = make(map[string]int, len(opts.RegistryConcurrency))
+	for registry, n := range opts.RegistryConcurrency {
+		if n < 1 {
+			return fmt.Errorf("registry-concurrency for %s must be at least 1, got: %d", registry, n)
+		}
+		host, err := transfer.RegistryHost(registry)
+		if err != nil {
+			return err
+		}
+		opts.registryLimits[host] = n
+	}
+	opts.rateLimiters = make(map[string]*rate.Limiter, len(opts.RegistryRates))
+	for registry, spec := range opts.RegistryRates {
+		host, err := transfer.RegistryHost(registry)
+		if err != nil {
+			return err
+		}
+		limiter, err := transfer.ParseRate(spec)
+		if err != nil {
+			return fmt.Errorf("registry-rate for %s: %w", registry, err)
+		}
+		opts.rateLimiters[host] = limiter
 	}
 
 	return nil
 }
 
-// isValidPlatform validates platform format (os/arch).
-func isValidPlatform(platform string) bool {
-	parts := strings.SplitN(platform, "/", 2)
-	return len(parts) == 2 && parts[0] != "" && parts[1] != ""
+// ImageTarget is the destination of an image in one ECR registry.
+type ImageTarget struct {
+	Client      *ecr.Client
+	TargetImage string
+	RepoName    string
 }
 
 // ImageJob represents a single image processing job.
 type ImageJob struct {
-	Index       int
-	Total       int
-	Image       string
-	TargetImage string
-	RepoName    string
+	Index    int
+	Total    int
+	Image    string
+	Registry string // canonical source registry host
+	Targets  []ImageTarget
+	// SourceKeychain authenticates the source pull; nil uses the local Docker config.
+	SourceKeychain authn.Keychain
+	// PullThrough jobs are served by pull-through cache rules; targets are the rewritten
+	// references, warmed with --warm instead of copied.
+	PullThrough bool
 }
 
 // JobResult represents the result of processing an image job.
 type JobResult struct {
-	Job     ImageJob
-	Error   error
-	Skipped bool
+	Job       ImageJob
+	Error     error
+	Targets   []TargetResult
+	Stats     transfer.ProgressSnapshot
+	Cancelled bool
+}
+
+// TargetResult is the outcome of one destination of an image job.
+type TargetResult struct {
+	Target   string `json:"target"`
+	Registry string `json:"registry"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Job statuses recorded in the push report. Targets are pushed, skipped or failed, or with
+// pull-through cached (warmed) or rewritten (not warmed); a job is partial when some of its
+// targets succeeded and others failed.
+const (
+	jobStatusPushed    = "pushed"
+	jobStatusCached    = "cached"
+	jobStatusRewritten = "rewritten"
+	jobStatusPartial   = "partial"
+	jobStatusSkipped   = "skipped"
+	jobStatusFailed    = "failed"
+	jobStatusCancelled = "cancelled"
+)
+
+// Status returns the outcome of the job.
+func (r JobResult) Status() string {
+	done := len(r.Done())
+	switch {
+	case r.Cancelled:
+		return jobStatusCancelled
+	case r.Error != nil && done > 0:
+		return jobStatusPartial
+	case r.Error != nil:
+		return jobStatusFailed
+	case done == 0:
+		return jobStatusSkipped
+	case r.Job.PullThrough:
+		return r.Targets[0].Status
+	default:
+		return jobStatusPushed
+	}
+}
+
+// Done returns the target references that serve the image after the job: pushed, or
+// cached or rewritten with pull-through.
+func (r JobResult) Done() []string {
+	var done []string
+	for _, t := range r.Targets {
+		switch t.Status {
+		case jobStatusPushed, jobStatusCached, jobStatusRewritten:
+			done = append(done, t.Target)
+		}
+	}
+	return done
+}
+
+// PushImageResult is the outcome of one image in the push report.
+type PushImageResult struct {
+	Image       string         `json:"image"`
+	Status      string         `json:"status"`
+	PullThrough bool           `json:"pullThrough,omitempty"`
+	Targets     []TargetResult `json:"targets,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Layers      int            `json:"layers"`
+	Bytes       int64          `json:"bytes"`
+	Duration    string         `json:"duration"`
+}
+
+// PushReport is the machine-readable record of a push run written with --report.
+type PushReport struct {
+	StartedAt   time.Time         `json:"startedAt"`
+	FinishedAt  time.Time         `json:"finishedAt"`
+	Interrupted bool              `json:"interrupted"`
+	Successful  int               `json:"successful"`
+	PullThrough int               `json:"pullThrough"`
+	Partial     int               `json:"partial"`
+	Skipped     int               `json:"skipped"`
+	Failed      int               `json:"failed"`
+	Cancelled   int               `json:"cancelled"`
+	Layers      int               `json:"layers"`
+	Bytes       int64             `json:"bytes"`
+	Images      []PushImageResult `json:"images"`
 }
 
 // newPushCmd constructs the push command with its own options.
@@ -92,50 +356,267 @@ func newPushCmd() *cobra.Command {
     
 This command discovers images from pods (optionally filtered by namespaces and patterns),
 creates ECR repositories if needed, and performs a registry-to-registry mirror preserving
-multi-arch manifests. Optionally restrict to a single platform with --platform.`,
+multi-arch manifests. Optionally restrict the platforms with --platform: a single platform
+is copied as a plain image, several produce a multi-arch index with only those platforms,
+and auto uses the platforms of the cluster's nodes.
+
+Repeat --region and add --target-account or --target-role-arn to mirror into several
+regions and accounts in one run. Images are discovered once and each source blob is
+downloaded once, then written to every destination registry.
+
+With --mode pull-through, images from registries that ECR pull-through cache supports are
+not copied. Instead a cache rule is created for each source registry and the rewritten
+references are printed; --warm pulls each image once through the cache. Images from other
+registries, or from registries that need credentials not given with --ptc-credential,
+fall back to copying.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.Namespace = globalNamespace
 			opts.AllNamespaces = globalAllNamespaces
-			if globalRegion != "" {
-				opts.Region = globalRegion
-			}
+			opts.Regions = utils.RemoveDuplicates(globalRegions)
 			if err := opts.ValidateWithCmd(cmd); err != nil {
 				return err
 			}
-			return runPush(cmd.Context(), opts)
+			ctx, cancel := deadlineContext(cmd.Context(), opts.Deadline)
+			defer cancel()
+			return runPush(ctx, opts)
 		},
 	}
 
 	// Global flags --namespace/-n, --all-namespaces/-A, --region/-r artık root seviyede
+	addDiscoveryFlags(cmd, opts)
+	cmd.Flags().StringVar(&opts.Mode, "mode", "copy", "How images reach ECR: copy or pull-through")
+	cmd.Flags().StringToStringVar(&opts.PTCCredentials, "ptc-credential", nil, "Secrets Manager ARN for pull-through upstreams that need credentials (e.g. docker-hub=arn:...)")
+	cmd.Flags().BoolVar(&opts.Warm, "warm", false, "With --mode pull-through, pull each image once to fill the cache")
+
+	cmd.Flags().BoolVar(&opts.WithSiblingTags, "with-sibling-tags", false, "Also mirror other tags of each image's repository, e.g. previous versions for rollbacks")
+	cmd.Flags().StringVar(&opts.SiblingTags.Semver, "sibling-semver", "", "With --with-sibling-tags, select tags by semver constraint (e.g. \">=1.20 <2\") instead of versions up to the running one")
+	cmd.Flags().StringVar(&opts.SiblingTags.Regex, "sibling-regex", "", "With --with-sibling-tags, select tags matching this regular expression")
+	cmd.Flags().IntVar(&opts.SiblingTags.Last, "sibling-last", 3, "With --with-sibling-tags, keep the N newest selected tags (0 = all)")
+	cmd.Flags().DurationVar(&opts.SiblingTags.MaxAge, "sibling-max-age", 0, "With --with-sibling-tags, skip tags whose image is older than this (e.g. 2160h)")
+	addTransferFlags(cmd, opts)
+	addCopyFlags(cmd, opts)
+
+	return cmd
+}
+
+// addDiscoveryFlags registers the flags selecting the cluster images to work on.
+func addDiscoveryFlags(cmd *cobra.Command, opts *PushOptions) {
+	cmd.Flags().StringSliceVar(&opts.IncludeNamespaces, "include-namespaces", nil, "Only include these namespaces "+namespacePatternHelp)
+	cmd.Flags().StringSliceVar(&opts.ExcludeNamespaces, "exclude-namespaces", nil, "Exclude these namespaces "+namespacePatternHelp)
+	cmd.Flags().StringSliceVarP(&opts.IncludePatterns, "include", "i", nil, "Only include images matching these patterns "+imagePatternHelp)
+	cmd.Flags().StringSliceVarP(&opts.ExcludePatterns, "exclude", "e", nil, "Exclude images matching these patterns "+imagePatternHelp)
+	cmd.Flags().BoolVar(&opts.UsePullSecrets, "use-pull-secrets", false, "Authenticate source pulls with the imagePullSecrets of the pods (and their service accounts) using each image")
+	cmd.Flags().StringVarP(&opts.LabelSelector, "selector", "l", "", "Only include pods matching this label selector (e.g. app=web,tier!=cache)")
+	cmd.Flags().StringVar(&opts.NamespaceSelector, "namespace-selector", "", "Only include namespaces whose labels match this selector (e.g. team=payments)")
+	cmd.Flags().StringVar(&opts.FieldSelector, "field-selector", "", "Only include pods matching this field selector (e.g. status.phase=Running)")
+	cmd.Flags().BoolVar(&opts.ExcludeTerminal, "exclude-terminal", false, "Skip images of pods that have completed, failed or were evicted")
+	cmd.Flags().BoolVar(&opts.IncludeEphemeral, "include-ephemeral", false, "Also mirror images of ephemeral (debug) containers")
+	cmd.Flags().BoolVar(&opts.IncludeNodeImages, "include-node-images", false, "Also mirror images cached on nodes (cannot be combined with namespace or pod filters)")
+}
+
+// addCopyFlags registers the flags of commands that copy a list of images into ECR.
+func addCopyFlags(cmd *cobra.Command, opts *PushOptions) {
+	cmd.Flags().BoolVarP(&opts.SkipExisting, "skip-existing", "S", false, "Skip mirroring if the target ECR tag already exists")
+	cmd.Flags().DurationVar(&opts.Deadline, "deadline", 0, "Stop starting new images after this duration for the whole run (e.g. 1h; 0 = no limit)")
+	cmd.Flags().DurationVar(&opts.GracePeriod, "grace-period", 30*time.Second, "After an interrupt or the deadline, how long in-flight transfers may finish before they are aborted")
+	cmd.Flags().StringVar(&opts.Report, "report", "", "Write a JSON report of every image's outcome to this file (also on interruption)")
+	cmd.Flags().StringVar(&opts.Progress, "progress", progressAuto, "Progress display: auto, bar (TTY), plain (periodic lines) or none")
+	cmd.Flags().DurationVar(&opts.ProgressInterval, "progress-interval", 10*time.Second, "Interval between plain progress lines")
+}
+
+// addTransferFlags registers the destination and transfer flags shared by commands that
+// write images into ECR.
+func addTransferFlags(cmd *cobra.Command, opts *PushOptions) {
 	cmd.Flags().StringVar(&opts.RepositoryPrefix, "prefix", "krane", "ECR repository prefix/namespace")
 	cmd.Flags().BoolVarP(&opts.DryRun, "dry-run", "d", false, "Show what would be pushed without actually pushing")
-	cmd.Flags().StringVarP(&opts.Platform, "platform", "p", "", "Limit mirror to a single platform (e.g. linux/amd64). If empty, mirror multi-arch when available.")
-	cmd.Flags().StringSliceVar(&opts.IncludeNamespaces, "include-namespaces", nil, "Only include these namespaces (prefix or regex; if regex compiles, it's used)")
-	cmd.Flags().StringSliceVar(&opts.ExcludeNamespaces, "exclude-namespaces", nil, "Exclude these namespaces (prefix or regex; if regex compiles, it's used)")
-	cmd.Flags().StringSliceVarP(&opts.IncludePatterns, "include", "i", nil, "Only include images matching these patterns (prefix or regex; if regex compiles, it's used)")
-	cmd.Flags().StringSliceVarP(&opts.ExcludePatterns, "exclude", "e", nil, "Exclude images matching these patterns (prefix or regex; if regex compiles, it's used)")
-	cmd.Flags().BoolVarP(&opts.SkipExisting, "skip-existing", "S", false, "Skip mirroring if the target ECR tag already exists")
+	cmd.Flags().StringVarP(&opts.Platform, "platform", "p", "", "Limit mirror to these platforms, os/arch[/variant][:os.version] (e.g. linux/amd64,linux/arm/v7), or auto for the node platforms. If empty, mirror all platforms.")
 	cmd.Flags().IntVarP(&opts.MaxConcurrent, "max-concurrent", "c", 3, "Maximum number of concurrent image transfers")
+	cmd.Flags().StringToIntVar(&opts.RegistryConcurrency, "registry-concurrency", nil, "Maximum concurrent transfers per source registry (e.g. docker.io=2,quay.io=5)")
+	cmd.Flags().StringVar(&opts.CacheDir, "cache-dir", "", "Keep source layers in this directory so they are downloaded once across images and runs")
+	cmd.Flags().StringVar(&opts.CacheSize, "cache-size", "10GiB", "Maximum size of --cache-dir; least recently used layers are evicted (0 = no limit)")
+	cmd.Flags().StringVar(&opts.Bandwidth, "bandwidth", "", "Limit registry traffic of all workers combined, uploads and downloads together (e.g. 50MiB/s)")
+	cmd.Flags().StringVar(&opts.UploadBandwidth, "upload-bandwidth", "", "Limit traffic with destination registries of all workers combined, in both directions (e.g. 20MiB/s)")
+	cmd.Flags().StringVar(&opts.DownloadBandwidth, "download-bandwidth", "", "Limit traffic with source registries of all workers combined, in both directions (e.g. 30MiB/s)")
+	cmd.Flags().StringToStringVar(&opts.RegistryRates, "registry-rate", nil, "Request rate per source registry as N/period, a token bucket allowing bursts of N (e.g. docker.io=5/s,ghcr.io=600/m)")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", 0, "Abort an image transfer that takes longer than this (e.g. 15m; 0 = no limit)")
+	cmd.Flags().StringSliceVar(&opts.TargetAccounts, "target-account", nil, "Mirror into these AWS accounts instead of the caller's by assuming --target-role-name in each (repeatable)")
+	cmd.Flags().StringVar(&opts.TargetRoleName, "target-role-name", "OrganizationAccountAccessRole", "Role name assumed in each --target-account")
+	cmd.Flags().StringSliceVar(&opts.TargetRoleARNs, "target-role-arn", nil, "Mirror into the accounts of these IAM roles, assumed via STS (repeatable)")
+}
 
-	return cmd
+// deadlineContext returns ctx limited to d, or ctx itself when d is 0.
+func deadlineContext(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
 }
 
 // runPush executes the push command with the given options.
 func runPush(ctx context.Context, opts *PushOptions) error {
-	fmt.Println("🚀 Starting image push to AWS ECR...")
+	logger.Info("starting image push to AWS ECR")
+	if err := opts.setupTransfer(); err != nil {
+		return err
+	}
 
-	// 1. Create ECR client
-	ecrClient, err := ecr.NewClient(opts.Region)
+	// 1. Create one ECR client per destination registry
+	phaseCtx, span := tracing.Start(ctx, "create ECR clients")
+	destinations, err := newDestinations(phaseCtx, opts)
+	tracing.End(span, err)
 	if err != nil {
-		return fmt.Errorf("creating ECR client: %w", err)
+		return err
 	}
 
-	fmt.Printf("🏷️  ECR Registry: %s\n", ecrClient.GetRegistryURL())
-
 	// 2. Get images from Kubernetes
+	phaseCtx, span = tracing.Start(ctx, "discover images")
+	uniqueImages, err := discoverPushImages(phaseCtx, opts)
+	span.SetAttributes(attribute.Int("krane.images", len(uniqueImages)))
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+	logger.Info("discovered images", "count", len(uniqueImages))
+
+	if opts.WithSiblingTags {
+		phaseCtx, span = tracing.Start(ctx, "list sibling tags")
+		uniqueImages, err = addSiblingTags(phaseCtx, uniqueImages, opts)
+		tracing.End(span, err)
+		if err != nil {
+			return err
+		}
+	}
+	kraneMetrics.ImagesDiscovered.Add(float64(len(uniqueImages)))
+
+	return pushImages(ctx, destinations, uniqueImages, opts)
+}
+
+// setupTransfer builds the registry transport shared by all transfers of the run, with
+// the registry settings, rate and bandwidth limits, and opens the blob cache.
+func (opts *PushOptions) setupTransfer() error {
+	registries, err := loadRegistries()
+	if err != nil {
+		return err
+	}
+	opts.registries = registries
+	transport, err := registryTransport(registries)
+	if err != nil {
+		return err
+	}
+	// Destinations are created later; their hosts are only looked up once transfers run
+	opts.bandwidth.IsDestination = func(host string) bool { return opts.destHosts[host] }
+	opts.transport = transfer.NewBandwidthLimitedTransport(
+		transfer.NewRateLimitedTransport(transport, opts.rateLimiters), opts.bandwidth)
+	opts.mounts = transfer.NewMountIndex()
+	if opts.CacheDir != "" {
+		blobCache, err := transfer.NewBlobCache(opts.CacheDir, opts.cacheSize)
+		if err != nil {
+			return err
+		}
+		opts.blobCache = blobCache
+	}
+	return nil
+}
+
+// pushImages authenticates against the destinations and copies images into them, or
+// serves them through pull-through cache rules with --mode pull-through.
+func pushImages(ctx context.Context, destinations []*ecr.Client, uniqueImages []string, opts *PushOptions) error {
+	// 3. Authenticate against every destination registry
+	phaseCtx, span := tracing.Start(ctx, "authenticate")
+	keychain, err := authenticateDestinations(phaseCtx, destinations)
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+	logger.Info("ECR authentication successful", "registries", len(destinations))
+
+	// 4. Serve supported registries through pull-through cache rules
+	var cached []string
+	if opts.Mode == "pull-through" {
+		phaseCtx, span = tracing.Start(ctx, "pull-through")
+		cached, uniqueImages, err = runPullThrough(phaseCtx, destinations, uniqueImages, opts)
+		tracing.End(span, err)
+		if err != nil {
+			return err
+		}
+		if len(uniqueImages) > 0 {
+			logger.Info("falling back to copy", "images", len(uniqueImages))
+		}
+	}
+
+	// 5. Process images concurrently
+	if opts.DryRun {
+		// For dry run, process sequentially to maintain clean output
+		for i, image := range uniqueImages {
+			for _, ecrClient := range destinations {
+				targetImage, _, err := ecrClient.ConvertImageName(image, opts.RepositoryPrefix)
+				if err != nil {
+					logger.Error("failed to convert image name", "image", image, "error", err)
+					break
+				}
+
+				logger.Info("dry run: would push", "index", i+1, "total", len(uniqueImages), "image", image, "target", targetImage)
+			}
+		}
+	} else {
+		// Process images concurrently
+		phaseCtx, span = tracing.Start(ctx, "copy images", attribute.Int("krane.images", len(uniqueImages)+len(cached)))
+		err := processImagesConcurrently(phaseCtx, destinations, keychain, uniqueImages, cached, opts)
+		tracing.End(span, err)
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Info("push operation completed")
+	return nil
+}
+
+// newDestinations creates one ECR client per target role and region.
+func newDestinations(ctx context.Context, opts *PushOptions) ([]*ecr.Client, error) {
+	regions := opts.Regions
+	if len(regions) == 0 {
+		regions = []string{""} // resolved from the AWS environment
+	}
+	var destinations []*ecr.Client
+	for _, roleARN := range opts.targetRoleARNs() {
+		for _, region := range regions {
+			clientOpts := awsOptions(region)
+			clientOpts.TargetRoleARN = roleARN
+			ecrClient, err := ecr.NewClient(ctx, clientOpts)
+			if err != nil {
+				return nil, fmt.Errorf("creating ECR client: %w", err)
+			}
+			logger.Info("using ECR registry", "registry", ecrClient.GetRegistryURL())
+			if opts.destHosts == nil {
+				opts.destHosts = map[string]bool{}
+			}
+			opts.destHosts[ecrClient.GetRegistryURL()] = true
+			destinations = append(destinations, ecrClient)
+		}
+	}
+	return destinations, nil
+}
+
+// discoverPushImages lists the unique, filtered images to push and resolves --platform auto.
+func discoverPushImages(ctx context.Context, opts *PushOptions) ([]string, error) {
 	k8sClient, err := k8s.NewClient("")
 	if err != nil {
-		return fmt.Errorf("creating Kubernetes client: %w", err)
+		return nil, fmt.Errorf("creating Kubernetes client: %w", err)
+	}
+
+	if opts.Platform == "auto" {
+		nodePlatforms, err := k8s.NodePlatforms(ctx, k8sClient)
+		if err != nil {
+			return nil, fmt.Errorf("detecting node platforms: %w", err)
+		}
+		if len(nodePlatforms) == 0 {
+			return nil, fmt.Errorf("no node platforms found for --platform auto")
+		}
+		opts.platforms, err = transfer.ParsePlatforms(strings.Join(nodePlatforms, ","))
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("detected node platforms", "platforms", transfer.FormatPlatforms(opts.platforms))
 	}
 
 	effectiveAllNamespaces := opts.AllNamespaces
@@ -144,101 +625,291 @@ func runPush(ctx context.Context, opts *PushOptions) error {
 	}
 
 	if !effectiveAllNamespaces && (len(opts.IncludeNamespaces) > 0 || len(opts.ExcludeNamespaces) > 0) {
-		fmt.Printf("⚠️ include/exclude namespaces flags only apply when --all-namespaces is used; with --namespace they are ignored.\n")
+		logger.Warn("include/exclude namespaces flags only apply when --all-namespaces is used; with --namespace they are ignored")
 	}
-	images, err := k8s.ListPodImagesFiltered(k8sClient, effectiveAllNamespaces, opts.Namespace, opts.IncludeNamespaces, opts.ExcludeNamespaces)
+	discovery := k8s.DiscoveryOptions{
+		AllNamespaces:     effectiveAllNamespaces,
+		Namespace:         opts.Namespace,
+		IncludeNamespaces: opts.IncludeNamespaces,
+		ExcludeNamespaces: opts.ExcludeNamespaces,
+		IncludeEphemeral:  opts.IncludeEphemeral,
+		IncludeNodeImages: opts.IncludeNodeImages,
+		LabelSelector:     opts.LabelSelector,
+		NamespaceSelector: opts.NamespaceSelector,
+		FieldSelector:     opts.FieldSelector,
+		SkipAnnotated:     true,
+		ExcludeTerminal:   opts.ExcludeTerminal,
+		Logger:            logger,
+	}
+	if err := discovery.Validate(); err != nil {
+		return nil, fmt.Errorf("--include-node-images: %w", err)
+	}
+	images, err := k8s.ListPodImagesFiltered(ctx, k8sClient, discovery)
 	if err != nil {
-		return fmt.Errorf("listing pod images: %w", err)
+		return nil, fmt.Errorf("listing pod images: %w", err)
+	}
+
+	if opts.UsePullSecrets {
+		opts.sourceKeychains, err = pullSecretKeychains(ctx, k8sClient, discovery)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	uniqueImages := utils.RemoveDuplicates(images)
 	// Apply image include/exclude filters
 	filtered, err := utils.FilterImages(uniqueImages, opts.IncludePatterns, opts.ExcludePatterns)
 	if err != nil {
-		return fmt.Errorf("invalid include/exclude patterns: %w", err)
+		return nil, fmt.Errorf("invalid include/exclude patterns: %w", err)
 	}
-	uniqueImages = filtered
-	fmt.Printf("📦 Found %d unique images\n", len(uniqueImages))
+	return filtered, nil
+}
 
-	// 3. Verify ECR authentication
-	_, _, err = ecrClient.GetAuthToken(ctx)
+// pullSecretKeychains returns a source keychain per image built from the imagePullSecrets of
+// the pods using it and their service accounts, falling back to the local Docker config.
+func pullSecretKeychains(ctx context.Context, k8sClient *kubernetes.Clientset, discovery k8s.DiscoveryOptions) (map[string]authn.Keychain, error) {
+	refsByImage, err := k8s.ListImagePullSecrets(ctx, k8sClient, discovery)
 	if err != nil {
-		return fmt.Errorf("getting ECR auth token: %w", err)
+		return nil, fmt.Errorf("listing image pull secrets: %w", err)
 	}
+	keychains, err := k8s.PullSecretKeychains(ctx, k8sClient, refsByImage, logger)
+	if err != nil {
+		return nil, err
+	}
+	for image, kc := range keychains {
+		keychains[image] = authn.NewMultiKeychain(kc, authn.DefaultKeychain)
+	}
+	logger.Info("using image pull secrets", "images", len(keychains))
+	return keychains, nil
+}
 
-	fmt.Println("🔑 ECR authentication successful")
-
-	// 5. Process images concurrently
-	if opts.DryRun {
-		// For dry run, process sequentially to maintain clean output
-		for i, image := range uniqueImages {
-			fmt.Printf("\n[%d/%d] 📦 Processing: %s\n", i+1, len(uniqueImages), image)
-
-			targetImage, _, err := ecrClient.ConvertImageName(image, opts.RepositoryPrefix)
-			if err != nil {
-				fmt.Printf("❌ Failed to convert image name %s: %v\n", image, err)
+// addSiblingTags adds the tags selected by --sibling-* from the repository of each tagged
+// image. Without --sibling-semver or --sibling-regex, versions up to the running one with
+// the same suffix are selected, and images whose tag is not a version (latest, a commit
+// SHA) get none. Siblings use the source credentials of their image.
+func addSiblingTags(ctx context.Context, images []string, opts *PushOptions) ([]string, error) {
+	tagsByRepo := map[string][]string{}
+	out := append([]string{}, images...)
+	for _, image := range images {
+		repo, tag := splitImageTag(image)
+		if tag == "" {
+			continue
+		}
+		filter := opts.SiblingTags
+		if filter.Semver == "" && filter.Regex == "" {
+			if !transfer.IsVersion(tag) {
+				logger.Debug("skipping sibling tags of an unversioned tag", "image", image)
 				continue
 			}
+			filter.SiblingOf = tag
+		}
+		sourceOpts := transfer.Options{
+			Platforms:      opts.platforms,
+			SourceKeychain: opts.sourceKeychains[image],
+			Transport:      opts.transport,
+			Registries:     opts.registries,
+		}
+		tags, ok := tagsByRepo[repo]
+		if !ok {
+			var err error
+			tags, err = transfer.ListTags(ctx, repo, sourceOpts)
+			if err != nil {
+				// A repository that cannot be listed still has its running tag mirrored
+				logger.Warn("could not list sibling tags", "image", image, "error", err)
+			}
+			tagsByRepo[repo] = tags
+		}
 
-			fmt.Printf("🔍 DRY RUN: Would push %s -> %s\n", image, targetImage)
+		selected, err := transfer.SelectTags(ctx, repo, tags, filter, sourceOpts)
+		if err != nil {
+			return nil, fmt.Errorf("selecting sibling tags of %s: %w", image, err)
 		}
-	} else {
-		// Process images concurrently
-		if err := processImagesConcurrently(ctx, ecrClient, uniqueImages, opts); err != nil {
-			return fmt.Errorf("concurrent processing failed: %w", err)
+		for _, t := range selected {
+			if t == tag {
+				continue // the running image itself
+			}
+			sibling := repo + ":" + t
+			if opts.sourceKeychains != nil && opts.sourceKeychains[image] != nil {
+				opts.sourceKeychains[sibling] = opts.sourceKeychains[image]
+			}
+			out = append(out, sibling)
 		}
+		logger.Debug("selected sibling tags", "image", image, "tags", selected)
 	}
+	out = utils.RemoveDuplicates(out)
+	logger.Info("added sibling tags", "images", len(out)-len(images))
+	return out, nil
+}
 
-	fmt.Println("\n🎉 Push operation completed!")
-	return nil
+// splitImageTag splits an image into its repository and tag, dropping any digest. The
+// tag is empty for untagged images.
+func splitImageTag(image string) (string, string) {
+	if at := strings.Index(image, "@"); at != -1 {
+		image = image[:at]
+	}
+	if idx := strings.LastIndex(image, ":"); idx != -1 && !strings.Contains(image[idx+1:], "/") {
+		return image[:idx], image[idx+1:]
+	}
+	return image, ""
 }
 
-// processImagesConcurrently processes images using worker pool pattern.
-func processImagesConcurrently(ctx context.Context, ecrClient *ecr.Client, images []string, opts *PushOptions) error {
-	// Prepare jobs
-	jobs := make([]ImageJob, 0, len(images))
-	for i, image := range images {
-		targetImage, repoName, err := ecrClient.ConvertImageName(image, opts.RepositoryPrefix)
+// authenticateDestinations fetches an auth token for every destination registry and returns
+// a keychain serving them, falling back to the local Docker config for source registries.
+func authenticateDestinations(ctx context.Context, destinations []*ecr.Client) (authn.Keychain, error) {
+	auths := make(map[string]authn.Authenticator, len(destinations))
+	for _, ecrClient := range destinations {
+		username, password, err := ecrClient.GetAuthToken(ctx)
 		if err != nil {
-			fmt.Printf("❌ Failed to convert image name %s: %v\n", image, err)
+			return nil, fmt.Errorf("getting ECR auth token for %s: %w", ecrClient.GetRegistryURL(), err)
+		}
+		auths[ecrClient.GetRegistryURL()] = authn.FromConfig(authn.AuthConfig{Username: username, Password: password})
+	}
+	return transfer.NewStaticKeychain(auths, authn.DefaultKeychain), nil
+}
+
+// runPullThrough creates pull-through cache rules in every destination for the registries of
+// the given images and prints the rewritten references. It returns the images served by the
+// rules, which are warmed by the worker pool with --warm, and those that still need to be copied.
+func runPullThrough(ctx context.Context, destinations []*ecr.Client, images []string, opts *PushOptions) ([]string, []string, error) {
+	var cached, remaining []string
+	var upstreams []ecr.PullThroughUpstream
+	seen := map[string]bool{}
+	for _, image := range images {
+		registry := utils.ParseImageReference(image).Registry
+		upstream, ok := ecr.PullThroughUpstreamFor(registry)
+		if !ok {
+			remaining = append(remaining, image)
 			continue
 		}
+		if upstream.CredentialRequired && opts.PTCCredentials[upstream.Name] == "" {
+			logger.Warn("pull-through upstream needs a credential, copying instead",
+				"image", image, "upstream", upstream.Name, "flag", "--ptc-credential "+upstream.Name+"=<secret-arn>")
+			remaining = append(remaining, image)
+			continue
+		}
+		if !seen[upstream.Name] {
+			seen[upstream.Name] = true
+			upstreams = append(upstreams, upstream)
+		}
+		cached = append(cached, image)
+	}
+	if len(cached) == 0 {
+		return nil, remaining, nil
+	}
 
-		jobs = append(jobs, ImageJob{
-			Index:       i + 1,
-			Total:       len(images),
-			Image:       image,
-			TargetImage: targetImage,
-			RepoName:    repoName,
-		})
+	logger.Info("serving images through pull-through cache", "images", len(cached))
+	for _, ecrClient := range destinations {
+		for _, upstream := range upstreams {
+			rulePrefix := upstream.RepositoryPrefix(opts.RepositoryPrefix)
+			if opts.DryRun {
+				logger.Info("dry run: would ensure pull-through rule", "prefix", rulePrefix, "upstream", upstream.URL, "registry", ecrClient.GetRegistryURL())
+				continue
+			}
+			created, err := ecrClient.CreatePullThroughCacheRule(ctx, opts.RepositoryPrefix, upstream, opts.PTCCredentials[upstream.Name])
+			if err != nil {
+				return nil, nil, err
+			}
+			if created {
+				logger.Info("created pull-through rule", "prefix", rulePrefix, "upstream", upstream.URL, "registry", ecrClient.GetRegistryURL())
+			}
+		}
+	}
+
+	for _, image := range cached {
+		for _, ecrClient := range destinations {
+			ref, _, _ := ecrClient.PullThroughImageName(image, opts.RepositoryPrefix)
+			fmt.Printf("%s -> %s\n", image, ref)
+		}
+	}
+	return cached, remaining, nil
+}
+
+// processImagesConcurrently processes images using worker pool pattern. Images are copied,
+// and images served by pull-through rules (cached) are warmed with --warm.
+func processImagesConcurrently(ctx context.Context, destinations []*ecr.Client, keychain authn.Keychain, images, cached []string, opts *PushOptions) error {
+	// Prepare jobs, one per image with a target in every destination
+	jobs := make([]ImageJob, 0, len(images)+len(cached))
+	total := len(images) + len(cached)
+	for i, image := range cached {
+		registry, err := transfer.ImageRegistryHost(image)
+		if err != nil {
+			logger.Error("failed to parse image name", "image", image, "error", err)
+			continue
+		}
+		job := ImageJob{Index: i + 1, Total: total, Image: image, Registry: registry, PullThrough: true}
+		for _, ecrClient := range destinations {
+			ref, _, _ := ecrClient.PullThroughImageName(image, opts.RepositoryPrefix)
+			job.Targets = append(job.Targets, ImageTarget{Client: ecrClient, TargetImage: ref})
+		}
+		jobs = append(jobs, job)
+	}
+	for i, image := range images {
+		registry, err := transfer.ImageRegistryHost(image)
+		if err != nil {
+			logger.Error("failed to parse image name", "image", image, "error", err)
+			continue
+		}
+		job := ImageJob{Index: len(cached) + i + 1, Total: total, Image: image, Registry: registry, SourceKeychain: opts.sourceKeychains[image]}
+		var convertErr error
+		for _, ecrClient := range destinations {
+			targetImage, repoName, err := ecrClient.ConvertImageName(image, opts.RepositoryPrefix)
+			if err != nil {
+				convertErr = err
+				break
+			}
+			job.Targets = append(job.Targets, ImageTarget{Client: ecrClient, TargetImage: targetImage, RepoName: repoName})
+		}
+		if convertErr != nil {
+			logger.Error("failed to convert image name", "image", image, "error", convertErr)
+			continue
+		}
+		jobs = append(jobs, job)
 	}
 
-	// Create channels
-	jobChan := make(chan ImageJob, len(jobs))
+	// Queue all jobs by source registry; workers stop starting them once ctx is done
+	dispatch := newDispatcher(jobs, opts.registryLimits)
 	resultChan := make(chan JobResult, len(jobs))
 
+	// In-flight transfers run on their own context so an interrupt or the deadline
+	// only stops new jobs; they are aborted once the grace period expires.
+	workCtx, abort := context.WithCancel(context.WithoutCancel(ctx))
+	defer abort()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-workCtx.Done():
+			return
+		}
+		dispatch.Drain()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			logger.Warn("deadline reached, no new images will be started", "gracePeriod", opts.GracePeriod)
+		} else {
+			logger.Warn("interrupted, no new images will be started; interrupt again to exit immediately", "gracePeriod", opts.GracePeriod)
+		}
+		timer := time.NewTimer(opts.GracePeriod)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			logger.Warn("grace period expired, aborting in-flight transfers")
+			abort()
+		case <-workCtx.Done():
+		}
+	}()
+
+	startedAt := time.Now()
+	reporter := newProgressReporter(opts.Progress, opts.ProgressInterval, len(jobs))
+	go reporter.Run()
+
 	// Start workers
 	var wg sync.WaitGroup
 	for i := 0; i < opts.MaxConcurrent; i++ {
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
-			worker(ctx, workerID, ecrClient, opts, jobChan, resultChan)
+			worker(ctx, workCtx, workerID, keychain, opts, reporter, dispatch, resultChan)
 		}(i)
 	}
 
-	// Send jobs
-	go func() {
-		defer close(jobChan)
-		for _, job := range jobs {
-			select {
-			case jobChan <- job:
-			case <-ctx.Done():
-				return
-			}
-		}
-	}()
-
 	// Collect results
 	go func() {
 		wg.Wait()
@@ -246,74 +917,260 @@ func processImagesConcurrently(ctx context.Context, ecrClient *ecr.Client, image
 	}()
 
 	// Process results
-	successCount := 0
-	errorCount := 0
-	skippedCount := 0
+	report := PushReport{StartedAt: startedAt}
 	for result := range resultChan {
-		if result.Skipped {
-			fmt.Printf("⏭️  [%d/%d] Skipped (already exists): %s\n",
-				result.Job.Index, result.Job.Total, result.Job.TargetImage)
-			skippedCount++
-		} else if result.Error != nil {
-			fmt.Printf("❌ [%d/%d] Failed %s: %v\n",
-				result.Job.Index, result.Job.Total, result.Job.Image, result.Error)
-			errorCount++
-		} else {
-			fmt.Printf("✅ [%d/%d] Successfully pushed: %s\n",
-				result.Job.Index, result.Job.Total, result.Job.TargetImage)
-			successCount++
+		job := result.Job
+		switch result.Status() {
+		case jobStatusCancelled:
+			logger.Warn("cancelled", "index", job.Index, "total", job.Total, "image", job.Image, "error", result.Error)
+			report.Cancelled++
+		case jobStatusFailed:
+			logger.Error("push failed", "index", job.Index, "total", job.Total, "image", job.Image, "error", result.Error)
+			report.Failed++
+		case jobStatusPartial:
+			logger.Error("push failed for some targets", "index", job.Index, "total", job.Total, "image", job.Image,
+				"done", result.Done(), "error", result.Error)
+			report.Partial++
+		case jobStatusCached, jobStatusRewritten:
+			logger.Info("served through pull-through cache", "index", job.Index, "total", job.Total, "image", job.Image,
+				"targets", result.Done(), "warmed", result.Status() == jobStatusCached)
+			report.PullThrough++
+		case jobStatusSkipped:
+			logger.Info("skipped, already exists", "index", job.Index, "total", job.Total, "image", job.Image)
+			report.Skipped++
+		default:
+			logger.Info("pushed image", append([]any{"index", job.Index, "total", job.Total, "image", job.Image,
+				"targets", result.Done()}, transferStatsAttrs(result.Stats)...)...)
+			report.Successful++
 		}
+		recordJobResult(result)
+		report.Images = append(report.Images, newPushImageResult(result))
 	}
+	reporter.Stop()
 
-	fmt.Printf("\n📊 Summary: %d successful, %d skipped, %d failed\n", successCount, skippedCount, errorCount)
+	totals := reporter.Totals()
+	report.FinishedAt = time.Now()
+	report.Interrupted = ctx.Err() != nil
+	report.Layers = totals.LayersDone
+	report.Bytes = totals.BytesDone
+	sort.Slice(report.Images, func(i, j int) bool { return report.Images[i].Image < report.Images[j].Image })
+
+	logger.Info("push summary", append([]any{"successful", report.Successful, "pullThrough", report.PullThrough, "partial", report.Partial,
+		"skipped", report.Skipped, "failed", report.Failed, "cancelled", report.Cancelled}, transferStatsAttrs(totals)...)...)
+	if opts.blobCache != nil {
+		stats := opts.blobCache.Stats()
+		logger.Info("blob cache", "hits", stats.Hits, "misses", stats.Misses, "blobs", stats.Blobs,
+			"size", utils.FormatBytes(stats.Size), "dir", opts.CacheDir)
+	}
+
+	if opts.Report != "" {
+		if err := writePushReport(opts.Report, report); err != nil {
+			return err
+		}
+		logger.Info("wrote push report", "path", opts.Report)
+	}
+	if report.Interrupted {
+		return fmt.Errorf("push stopped early: %d images cancelled", report.Cancelled)
+	}
+	warmFailed := 0
+	for _, img := range report.Images {
+		if img.PullThrough && (img.Status == jobStatusFailed || img.Status == jobStatusPartial) {
+			warmFailed++
+		}
+	}
+	if warmFailed > 0 {
+		return fmt.Errorf("warming the pull-through cache failed for %d images", warmFailed)
+	}
 	return nil
 }
 
-// worker processes jobs from the job channel.
-func worker(ctx context.Context, workerID int, ecrClient *ecr.Client, opts *PushOptions, jobs <-chan ImageJob, results chan<- JobResult) {
-	for job := range jobs {
-		fmt.Printf("🔄 [%d/%d] Worker %d processing: %s\n",
-			job.Index, job.Total, workerID, job.Image)
+// newPushImageResult converts a job result into its report entry.
+func newPushImageResult(r JobResult) PushImageResult {
+	out := PushImageResult{
+		Image:       r.Job.Image,
+		Status:      r.Status(),
+		PullThrough: r.Job.PullThrough,
+		Targets:     r.Targets,
+		Layers:      r.Stats.LayersDone,
+		Bytes:       r.Stats.BytesDone,
+		Duration:    r.Stats.Elapsed.Round(time.Millisecond).String(),
+	}
+	if r.Error != nil {
+		out.Error = r.Error.Error()
+	}
+	return out
+}
 
-		err, skipped := processImageJob(ctx, ecrClient, opts, job)
+// writePushReport writes the push report as indented JSON.
+func writePushReport(path string, report PushReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling push report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing push report: %w", err)
+	}
+	return nil
+}
 
-		select {
-		case results <- JobResult{Job: job, Error: err, Skipped: skipped}:
-		case <-ctx.Done():
+// worker processes jobs handed out by the dispatcher. Jobs taken after ctx is done are
+// reported as cancelled without being started; running jobs use workCtx, limited by --timeout.
+func worker(ctx, workCtx context.Context, workerID int, keychain authn.Keychain, opts *PushOptions, reporter *progressReporter, dispatch *dispatcher, results chan<- JobResult) {
+	for {
+		job, ok := dispatch.Next()
+		if !ok {
 			return
 		}
+		if ctx.Err() != nil {
+			reporter.End(job)
+			dispatch.Done(job)
+			results <- JobResult{Job: job, Error: errors.New("not started"), Cancelled: true}
+			continue
+		}
+
+		logger.Info("processing image", "index", job.Index, "total", job.Total, "worker", workerID, "image", job.Image)
+
+		jobCtx, cancel := workCtx, context.CancelFunc(func() {})
+		if opts.Timeout > 0 {
+			jobCtx, cancel = context.WithTimeout(workCtx, opts.Timeout)
+		}
+		jobCtx, span := tracing.Start(jobCtx, "image job",
+			attribute.String("krane.image", job.Image),
+			attribute.Int("krane.index", job.Index),
+			attribute.Int("krane.targets", len(job.Targets)))
+		progress := reporter.Begin(job)
+		targets, err := processImageJob(jobCtx, keychain, opts, job, progress)
+		stats := reporter.End(job)
+		span.SetAttributes(attribute.Int("krane.layers", stats.LayersDone), attribute.Int64("krane.bytes", stats.BytesDone))
+		tracing.End(span, err)
+		cancel()
+		dispatch.Done(job)
+
+		cancelled := err != nil && workCtx.Err() != nil
+		if err != nil && !cancelled && errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s: %w", opts.Timeout, err)
+		}
+		results <- JobResult{Job: job, Error: err, Targets: targets, Stats: stats, Cancelled: cancelled}
 	}
 }
 
-// processImageJob processes a single image job.
-func processImageJob(ctx context.Context, ecrClient *ecr.Client, opts *PushOptions, job ImageJob) (error, bool) {
-	// Create ECR repository
-	if err := ecrClient.CreateRepository(ctx, job.RepoName); err != nil {
-		return fmt.Errorf("failed to create repository %s: %w", job.RepoName, err), false
+// processImageJob processes a single image job and returns the outcome of every target.
+// Targets whose tag already exists are skipped when --skip-existing is set. A failed target
+// does not stop the others; the returned error joins the errors of all failed targets.
+func processImageJob(ctx context.Context, keychain authn.Keychain, opts *PushOptions, job ImageJob, progress *transfer.Progress) ([]TargetResult, error) {
+	if job.PullThrough {
+		return warmPullThroughJob(ctx, keychain, opts, job)
 	}
+	results := make([]TargetResult, len(job.Targets))
+	var errs []error
+	fail := func(i int, err error) {
+		results[i].Status = jobStatusFailed
+		results[i].Error = err.Error()
+		errs = append(errs, err)
+	}
+
+	var pending []string
+	pendingIndex := map[string]int{}
+	for i, target := range job.Targets {
+		results[i] = TargetResult{Target: target.TargetImage, Registry: target.Client.GetRegistryURL()}
 
-	// If skipping existing, check whether tag exists already in ECR
-	if opts.SkipExisting {
-		// Extract tag from targetImage (after last ':')
-		tag := ""
-		if idx := strings.LastIndex(job.TargetImage, ":"); idx != -1 {
-			tag = job.TargetImage[idx+1:]
+		// Create ECR repository
+		if err := target.Client.CreateRepository(ctx, target.RepoName); err != nil {
+			fail(i, fmt.Errorf("failed to create repository %s: %w", target.RepoName, err))
+			continue
 		}
-		if tag != "" {
-			exists, err := ecrClient.ImageTagExists(ctx, job.RepoName, tag)
-			if err != nil {
-				return fmt.Errorf("could not check existing tag for %s:%s: %w", job.RepoName, tag, err), false
+
+		// If skipping existing, check whether tag exists already in ECR
+		if opts.SkipExisting {
+			// Extract tag from targetImage (after last ':')
+			tag := ""
+			if idx := strings.LastIndex(target.TargetImage, ":"); idx != -1 {
+				tag = target.TargetImage[idx+1:]
 			}
-			if exists {
-				return nil, true // Skipped, not an error
+			if tag != "" {
+				exists, err := target.Client.ImageTagExists(ctx, target.RepoName, tag)
+				if err != nil {
+					fail(i, fmt.Errorf("could not check existing tag for %s:%s: %w", target.RepoName, tag, err))
+					continue
+				}
+				if exists {
+					results[i].Status = jobStatusSkipped // Skipped, not an error
+					continue
+				}
 			}
 		}
+		pending = append(pending, target.TargetImage)
+		pendingIndex[target.TargetImage] = i
+	}
+	if len(pending) == 0 {
+		return results, errors.Join(errs...)
 	}
 
-	// Mirror source image to ECR preserving manifest lists (or single platform if provided)
-	if err := transfer.Mirror(ctx, job.Image, job.TargetImage, opts.Platform); err != nil {
-		return fmt.Errorf("mirror failed %s -> %s: %w", job.Image, job.TargetImage, err), false
+	// Mirror source image to every target, preserving manifest lists (filtered to platforms if provided)
+	err := transfer.Mirror(ctx, job.Image, pending, transfer.Options{
+		Platforms:           opts.platforms,
+		SourceKeychain:      job.SourceKeychain,
+		DestinationKeychain: keychain,
+		Progress:            progress,
+		Logger:              logger,
+		Transport:           opts.transport,
+		BlobCache:           opts.blobCache,
+		Mounts:              opts.mounts,
+		Registries:          opts.registries,
+	})
+	failed := transfer.FailedDestinations(err)
+	for _, target := range pending {
+		i := pendingIndex[target]
+		switch {
+		case failed[target] != nil:
+			fail(i, fmt.Errorf("mirror failed %s to %s: %w", job.Image, target, failed[target]))
+		case err != nil && len(failed) == 0:
+			// The source could not be read, so no target was written
+			results[i].Status = jobStatusFailed
+			results[i].Error = err.Error()
+		default:
+			results[i].Status = jobStatusPushed
+		}
 	}
+	if err != nil && len(failed) == 0 {
+		errs = append(errs, fmt.Errorf("mirror failed %s: %w", job.Image, err))
+	}
+	return results, errors.Join(errs...)
+}
 
-	return nil, false
+// warmPullThroughJob pulls the rewritten reference of every target once with --warm so the
+// pull-through cache stores the image; without it, the targets are only reported as rewritten.
+func warmPullThroughJob(ctx context.Context, keychain authn.Keychain, opts *PushOptions, job ImageJob) ([]TargetResult, error) {
+	results := make([]TargetResult, len(job.Targets))
+	var errs []error
+	for i, target := range job.Targets {
+		results[i] = TargetResult{Target: target.TargetImage, Registry: target.Client.GetRegistryURL(), Status: jobStatusRewritten}
+		if !opts.Warm {
+			continue
+		}
+		if err := transfer.Warm(ctx, target.TargetImage, transfer.Options{
+			Platforms:      opts.platforms,
+			SourceKeychain: keychain,
+			Transport:      opts.transport,
+		}); err != nil {
+			err = fmt.Errorf("failed to warm cache %s: %w", target.TargetImage, err)
+			results[i].Status = jobStatusFailed
+			results[i].Error = err.Error()
+			errs = append(errs, err)
+			continue
+		}
+		results[i].Status = jobStatusCached
+	}
+	return results, errors.Join(errs...)
+}
+
+// transferStatsAttrs returns layer and byte counts with duration and average rate as log attributes.
+func transferStatsAttrs(s transfer.ProgressSnapshot) []any {
+	return []any{
+		"layers", s.LayersDone,
+		"bytes", s.BytesDone,
+		"size", utils.FormatBytes(s.BytesDone),
+		"duration", s.Elapsed.Round(time.Second),
+		"bytesPerSecond", int64(s.Rate()),
+	}
 }
@@ -0,0 +1,97 @@
+/*
+Copyright © 2025 Krane CLI menbiyagoral@gmail.com
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"krane/pkg/transfer"
+)
+
+// Registry connection flags (available to all subcommands)
+var (
+	globalRegistriesConfig   string
+	globalInsecureRegistries []string
+	globalRegistryCAs        map[string]string
+	globalRegistryCerts      map[string]string
+	globalRegistryKeys       map[string]string
+	globalRegistryProxies    map[string]string
+	globalRegistryMirrors    []string
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&globalRegistriesConfig, "registries-config", "", "YAML file with per-registry settings (insecure, caFile, certFile, keyFile, proxy, mirrors)")
+	rootCmd.PersistentFlags().StringSliceVar(&globalInsecureRegistries, "insecure-registry", nil, "Allow plain HTTP and unverified TLS for these registries (repeatable)")
+	rootCmd.PersistentFlags().StringToStringVar(&globalRegistryCAs, "registry-ca", nil, "PEM CA bundle trusted for a registry (e.g. harbor.internal=/etc/ssl/corp-ca.pem)")
+	rootCmd.PersistentFlags().StringToStringVar(&globalRegistryCerts, "registry-cert", nil, "PEM client certificate for a registry, used with --registry-key")
+	rootCmd.PersistentFlags().StringToStringVar(&globalRegistryKeys, "registry-key", nil, "PEM client key for a registry, used with --registry-cert")
+	rootCmd.PersistentFlags().StringToStringVar(&globalRegistryProxies, "registry-proxy", nil, "HTTP proxy URL for a registry (e.g. ghcr.io=http://proxy:3128)")
+	rootCmd.PersistentFlags().StringArrayVar(&globalRegistryMirrors, "registry-mirror", nil, "Pull a registry's images from this mirror first, as REGISTRY=HOST[/PATH] (repeatable, tried in order)")
+}
+
+// loadRegistries returns the per-registry settings of --registries-config with the
+// registry flags applied on top.
+func loadRegistries() (transfer.Registries, error) {
+	registries := transfer.Registries{}
+	if globalRegistriesConfig != "" {
+		loaded, err := transfer.LoadRegistries(globalRegistriesConfig)
+		if err != nil {
+			return nil, err
+		}
+		registries = loaded
+	}
+
+	entry := func(flag, registry string) (*transfer.RegistryConfig, error) {
+		cfg, err := registries.Entry(registry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", flag, err)
+		}
+		return cfg, nil
+	}
+	for _, registry := range globalInsecureRegistries {
+		cfg, err := entry("insecure-registry", registry)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Insecure = true
+	}
+	for _, setting := range []struct {
+		flag   string
+		values map[string]string
+		field  func(*transfer.RegistryConfig) *string
+	}{
+		{"registry-ca", globalRegistryCAs, func(c *transfer.RegistryConfig) *string { return &c.CAFile }},
+		{"registry-cert", globalRegistryCerts, func(c *transfer.RegistryConfig) *string { return &c.CertFile }},
+		{"registry-key", globalRegistryKeys, func(c *transfer.RegistryConfig) *string { return &c.KeyFile }},
+		{"registry-proxy", globalRegistryProxies, func(c *transfer.RegistryConfig) *string { return &c.Proxy }},
+	} {
+		for registry, value := range setting.values {
+			cfg, err := entry(setting.flag, registry)
+			if err != nil {
+				return nil, err
+			}
+			*setting.field(cfg) = value
+		}
+	}
+
+	// Mirrors given as flags replace those of the file for the same registry
+	replaced := map[*transfer.RegistryConfig]bool{}
+	for _, spec := range globalRegistryMirrors {
+		registry, mirror, ok := strings.Cut(spec, "=")
+		if !ok || registry == "" || mirror == "" {
+			return nil, fmt.Errorf("registry-mirror must be REGISTRY=HOST[/PATH], got: %s", spec)
+		}
+		cfg, err := entry("registry-mirror", registry)
+		if err != nil {
+			return nil, err
+		}
+		if !replaced[cfg] {
+			cfg.Mirrors = nil
+			replaced[cfg] = true
+		}
+		cfg.Mirrors = append(cfg.Mirrors, mirror)
+	}
+	return registries, nil
+}
@@ -4,9 +4,18 @@ Copyright © 2025 Krane CLI menbiyagoral@gmail.com
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
+	"krane/pkg/ecr"
+	"krane/pkg/tracing"
+
+	"github.com/aws/smithy-go/middleware"
 	"github.com/spf13/cobra"
 )
 
@@ -14,8 +23,35 @@ import (
 var (
 	globalNamespace     string
 	globalAllNamespaces bool
-	globalRegion        string
+	globalRegions       []string
 	globalOutput        string
+
+	globalProfile         string
+	globalRoleARN         string
+	globalExternalID      string
+	globalRoleSessionName string
+	globalECREndpoint     string
+	globalAccountID       string
+	globalUseFIPS         bool
+	globalUseDualStack    bool
+
+	globalVerbosity int
+	globalQuiet     bool
+	globalLogFormat string
+
+	globalMetricsAddr    string
+	globalMetricsFile    string
+	globalPushgateway    string
+	globalPushgatewayJob string
+
+	globalOTLPEndpoint string
+	globalOTLPInsecure bool
+)
+
+// Help suffixes for flags taking patterns (see package match for the syntax).
+const (
+	imagePatternHelp     = "(prefix by default; re:, glob:, exact:, registry:/repository:/tag:, i: and ! supported)"
+	namespacePatternHelp = "(prefix by default; re:, glob:, exact:, i: and ! supported)"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -27,25 +63,91 @@ var rootCmd = &cobra.Command{
 Krane helps you:
 - List all container images running in your Kubernetes pods
 - Push container images to AWS ECR for backup and migration
+- Mirror selected tags of a repository, e.g. previous versions for rollbacks
 - Manage container images across different namespaces
 - Convert Docker Hub images to ECR format automatically
+- Inventory and prune mirrored images in ECR
+- Map ECR vulnerability findings back to workloads
+- Audit cluster images against image policy rules
+- Find every workload and container using an image
 
 Examples:
   krane list -n default                # List images in default namespace
   krane list -A                        # List images from all namespaces
   krane push -r eu-west-1              # Push images to ECR in eu-west-1
-  krane push -d                        # Preview what would be pushed`,
+  krane push -r eu-west-1,eu-central-1 # Push to several regions in one run
+  krane push -d                        # Preview what would be pushed
+  krane mirror-tags nginx --semver ">=1.26 <2" --last 3 # Mirror the three newest 1.x versions
+  krane sync -A --policy update        # Follow upstream tags that moved since mirroring
+  krane ecr list                       # Inventory mirrored images in ECR
+  krane ecr prune --older-than 30d     # Preview pruning of unused images
+  krane scan-report --min-severity HIGH # Fail if any workload has HIGH+ findings
+  krane audit -o sarif                 # Audit images and emit SARIF for CI
+  krane who-uses "glob:*log4j*"        # Find workloads using images containing log4j`,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		l, err := newLogger(globalLogFormat, globalVerbosity, globalQuiet)
+		if err != nil {
+			return err
+		}
+		logger = l
+		slog.SetDefault(l)
+		if globalAccountID != "" && !isValidAccountID(globalAccountID) {
+			return fmt.Errorf("invalid account-id, expected 12 digits: %s", globalAccountID)
+		}
+		if err := startTracing(cmd); err != nil {
+			return err
+		}
+		return serveMetrics(cmd.Context())
+	},
+}
+
+// singleRegion returns the --region value for commands that work against one registry.
+func singleRegion() (string, error) {
+	if len(globalRegions) > 1 {
+		return "", fmt.Errorf("only push supports multiple regions, got: %s", strings.Join(globalRegions, ", "))
+	}
+	if len(globalRegions) == 0 {
+		return "", nil
+	}
+	return globalRegions[0], nil
+}
+
+// awsOptions returns the ECR client options for a region from the global AWS flags.
+func awsOptions(region string) ecr.Options {
+	return ecr.Options{
+		Region:       region,
+		Profile:      globalProfile,
+		RoleARN:      globalRoleARN,
+		ExternalID:   globalExternalID,
+		SessionName:  globalRoleSessionName,
+		Endpoint:     globalECREndpoint,
+		AccountID:    globalAccountID,
+		UseFIPS:      globalUseFIPS,
+		UseDualStack: globalUseDualStack,
+		Logger:       logger,
+		APIOptions:   []func(*middleware.Stack) error{tracing.AWSMiddleware, kraneMetrics.AWSMiddleware},
+	}
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	// Cancel the context on SIGINT/SIGTERM; a second signal terminates immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := rootCmd.ExecuteContext(ctx)
+	stopTracing(err)
+	exportMetrics()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
 		os.Exit(1)
@@ -56,9 +158,32 @@ func init() {
 	// Persistent global flags
 	rootCmd.PersistentFlags().StringVarP(&globalNamespace, "namespace", "n", "", "Kubernetes namespace to use (default: all)")
 	rootCmd.PersistentFlags().BoolVarP(&globalAllNamespaces, "all-namespaces", "A", false, "If true, use all namespaces")
-	rootCmd.PersistentFlags().StringVarP(&globalRegion, "region", "r", "eu-west-1", "AWS region for ECR")
-	rootCmd.PersistentFlags().StringVarP(&globalOutput, "output", "o", "table", "Global output format (table, json, yaml)")
+	rootCmd.PersistentFlags().StringSliceVarP(&globalRegions, "region", "r", nil, "AWS region for ECR, repeatable for push (default: AWS_REGION, the profile's region or eu-west-1)")
+	rootCmd.PersistentFlags().StringVar(&globalProfile, "profile", "", "AWS shared config profile to use")
+	rootCmd.PersistentFlags().StringVar(&globalRoleARN, "role-arn", "", "IAM role to assume via STS before calling AWS")
+	rootCmd.PersistentFlags().StringVar(&globalExternalID, "external-id", "", "External ID passed when assuming --role-arn")
+	rootCmd.PersistentFlags().StringVar(&globalRoleSessionName, "role-session-name", "krane", "Session name for assumed roles")
+	rootCmd.PersistentFlags().StringVar(&globalECREndpoint, "ecr-endpoint", "", "Override the ECR and STS API endpoints (e.g. http://localhost:4566 for a local stand-in)")
+	rootCmd.PersistentFlags().StringVar(&globalAccountID, "account-id", "", "AWS account ID of the registry; skips the STS caller identity lookup")
+	rootCmd.PersistentFlags().BoolVar(&globalUseFIPS, "use-fips", false, "Use FIPS endpoints for ECR and STS")
+	rootCmd.PersistentFlags().BoolVar(&globalUseDualStack, "use-dual-stack", false, "Use dual-stack (IPv4/IPv6) endpoints for ECR and STS")
+	rootCmd.PersistentFlags().IntVarP(&globalVerbosity, "verbosity", "v", 0, "Log verbosity: 0 info, 1 debug, 2 trace")
+	rootCmd.PersistentFlags().BoolVarP(&globalQuiet, "quiet", "q", false, "Only log errors")
+	rootCmd.PersistentFlags().StringVar(&globalLogFormat, "log-format", "text", "Log format on stderr (text, json)")
+	rootCmd.PersistentFlags().StringVar(&globalMetricsAddr, "metrics-addr", "", "Serve Prometheus metrics on this address (e.g. :9090) while the command runs")
+	rootCmd.PersistentFlags().StringVar(&globalMetricsFile, "metrics-file", "", "Write Prometheus metrics to this file when the command ends (node_exporter textfile format)")
+	rootCmd.PersistentFlags().StringVar(&globalPushgateway, "pushgateway", "", "Push Prometheus metrics to this Pushgateway URL when the command ends")
+	rootCmd.PersistentFlags().StringVar(&globalPushgatewayJob, "pushgateway-job", "krane", "Job name for metrics pushed to the Pushgateway")
+	rootCmd.PersistentFlags().StringVar(&globalOTLPEndpoint, "otlp-endpoint", "", "Export OpenTelemetry traces to this OTLP/HTTP endpoint (host:port or URL; default: OTEL_EXPORTER_OTLP_ENDPOINT)")
+	rootCmd.PersistentFlags().BoolVar(&globalOTLPInsecure, "otlp-insecure", false, "Use plain HTTP for a host:port --otlp-endpoint")
+	rootCmd.PersistentFlags().StringVarP(&globalOutput, "output", "o", "table", "Global output format (table, json, yaml; list also supports wide)")
 
 	rootCmd.AddCommand(newListCmd())
 	rootCmd.AddCommand(newPushCmd())
+	rootCmd.AddCommand(newMirrorTagsCmd())
+	rootCmd.AddCommand(newSyncCmd())
+	rootCmd.AddCommand(newECRCmd())
+	rootCmd.AddCommand(newScanReportCmd())
+	rootCmd.AddCommand(newAuditCmd())
+	rootCmd.AddCommand(newWhoUsesCmd())
 }
@@ -0,0 +1,426 @@
+/*
+Copyright © 2025 Krane CLI menbiyagoral@gmail.com
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"krane/pkg/ecr"
+	"krane/pkg/k8s"
+	"krane/pkg/transfer"
+	"krane/pkg/utils"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// ScanReportOptions holds flag values for the scan-report command.
+type ScanReportOptions struct {
+	AllNamespaces     bool
+	Namespace         string
+	Region            string
+	RepositoryPrefix  string
+	Format            string
+	MinSeverity       string
+	Platform          string
+	IncludeNamespaces []string
+	ExcludeNamespaces []string
+	IncludePatterns   []string
+	ExcludePatterns   []string
+
+	platforms []v1.Platform
+}
+
+// Validate validates scan-report command options and returns error if invalid.
+func (opts *ScanReportOptions) Validate() error {
+	validFormats := map[string]bool{"table": true, "json": true, "yaml": true, "csv": true}
+	if !validFormats[opts.Format] {
+		return fmt.Errorf("invalid format: %s (valid: table, json, yaml, csv)", opts.Format)
+	}
+	if opts.MinSeverity != "" {
+		opts.MinSeverity = strings.ToUpper(opts.MinSeverity)
+		if ecr.SeverityRank(opts.MinSeverity) < 0 {
+			return fmt.Errorf("invalid min-severity: %s (valid: %s)", opts.MinSeverity, strings.Join(ecr.Severities, ", "))
+		}
+	}
+	if opts.Platform != "" {
+		platforms, err := transfer.ParsePlatforms(opts.Platform)
+		if err != nil {
+			return err
+		}
+		opts.platforms = platforms
+	}
+	return nil
+}
+
+// newScanReportCmd constructs the scan-report command with its own options.
+func newScanReportCmd() *cobra.Command {
+	opts := &ScanReportOptions{Format: "table"}
+	cmd := &cobra.Command{
+		Use:   "scan-report",
+		Short: "Report ECR scan findings per workload and namespace",
+		Long: `Fetch ECR image scan findings for every mirrored image and map them back
+to the workloads and namespaces that run the image.
+
+With --min-severity only findings at or above that severity are counted and
+the command exits with a non-zero status when any are found.
+
+ECR scans the platform images of a multi-arch image rather than its index, so
+their findings are added up; --platform limits which platforms are counted.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Namespace = globalNamespace
+			opts.AllNamespaces = globalAllNamespaces
+			region, err := singleRegion()
+			if err != nil {
+				return err
+			}
+			opts.Region = region
+			if globalOutput != "" {
+				opts.Format = globalOutput
+			}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return runScanReport(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.RepositoryPrefix, "prefix", "krane", "ECR repository prefix/namespace")
+	cmd.Flags().StringVarP(&opts.Platform, "platform", "p", "", "Only count findings of these platforms of multi-arch images, os/arch[/variant] (e.g. linux/amd64,linux/arm64). If empty, count all platforms.")
+	cmd.Flags().StringVar(&opts.MinSeverity, "min-severity", "", "Only count findings at or above this severity and fail if any are found (CRITICAL, HIGH, MEDIUM, LOW, INFORMATIONAL, UNDEFINED)")
+	cmd.Flags().StringSliceVar(&opts.IncludeNamespaces, "include-namespaces", nil, "Only include these namespaces "+namespacePatternHelp)
+	cmd.Flags().StringSliceVar(&opts.ExcludeNamespaces, "exclude-namespaces", nil, "Exclude these namespaces "+namespacePatternHelp)
+	cmd.Flags().StringSliceVarP(&opts.IncludePatterns, "include", "i", nil, "Only include images matching these patterns "+imagePatternHelp)
+	cmd.Flags().StringSliceVarP(&opts.ExcludePatterns, "exclude", "e", nil, "Exclude images matching these patterns "+imagePatternHelp)
+
+	return cmd
+}
+
+// ImageScan is the scan result of a single cluster image.
+type ImageScan struct {
+	Image            string `json:"image" yaml:"image"`
+	ecr.ScanFindings `yaml:",inline"`
+}
+
+// WorkloadScan aggregates finding counts for one workload.
+type WorkloadScan struct {
+	Namespace string         `json:"namespace" yaml:"namespace"`
+	Kind      string         `json:"kind" yaml:"kind"`
+	Name      string         `json:"name" yaml:"name"`
+	Images    []string       `json:"images" yaml:"images"`
+	Counts    map[string]int `json:"counts" yaml:"counts"`
+}
+
+// NamespaceScan aggregates finding counts for one namespace.
+type NamespaceScan struct {
+	Namespace string         `json:"namespace" yaml:"namespace"`
+	Workloads int            `json:"workloads" yaml:"workloads"`
+	Images    int            `json:"images" yaml:"images"`
+	Counts    map[string]int `json:"counts" yaml:"counts"`
+}
+
+// ScanReport represents the structure for scan-report output.
+type ScanReport struct {
+	MinSeverity string          `json:"minSeverity,omitempty" yaml:"minSeverity,omitempty"`
+	Images      []ImageScan     `json:"images" yaml:"images"`
+	Workloads   []WorkloadScan  `json:"workloads" yaml:"workloads"`
+	Namespaces  []NamespaceScan `json:"namespaces" yaml:"namespaces"`
+	Totals      map[string]int  `json:"totals" yaml:"totals"`
+}
+
+// runScanReport executes the scan-report command with the given options.
+func runScanReport(ctx context.Context, opts *ScanReportOptions) error {
+	ecrClient, err := ecr.NewClient(ctx, awsOptions(opts.Region))
+	if err != nil {
+		return fmt.Errorf("creating ECR client: %w", err)
+	}
+
+	client, err := k8s.NewClient("")
+	if err != nil {
+		return fmt.Errorf("creating Kubernetes client: %w", err)
+	}
+
+	effectiveAllNamespaces := opts.AllNamespaces
+	if strings.TrimSpace(opts.Namespace) == "" {
+		effectiveAllNamespaces = true
+	}
+
+	infos, err := k8s.ListPodImagesWithSource(ctx, client, k8s.DiscoveryOptions{
+		AllNamespaces:     effectiveAllNamespaces,
+		Namespace:         opts.Namespace,
+		IncludeNamespaces: opts.IncludeNamespaces,
+		ExcludeNamespaces: opts.ExcludeNamespaces,
+		Logger:            logger,
+	})
+	if err != nil {
+		return fmt.Errorf("listing pod images: %w", err)
+	}
+
+	report, err := buildScanReport(ctx, ecrClient, infos, opts)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Format {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	case "yaml":
+		data, err := yaml.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("marshaling YAML: %w", err)
+		}
+		fmt.Println(string(data))
+	case "csv":
+		if err := printScanReportCSV(report); err != nil {
+			return fmt.Errorf("writing CSV: %w", err)
+		}
+	default:
+		printScanReportTable(report)
+	}
+
+	if opts.MinSeverity != "" {
+		if total := sumCounts(report.Totals); total > 0 {
+			return fmt.Errorf("found %d findings at or above %s severity", total, opts.MinSeverity)
+		}
+	}
+	return nil
+}
+
+// buildScanReport fetches scan findings for the discovered images and joins them with their sources.
+func buildScanReport(ctx context.Context, ecrClient *ecr.Client, infos []k8s.ImageInfo, opts *ScanReportOptions) (*ScanReport, error) {
+	var images []string
+	for _, info := range infos {
+		images = append(images, info.Image)
+	}
+	images, err := utils.FilterImages(utils.RemoveDuplicates(images), opts.IncludePatterns, opts.ExcludePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include/exclude patterns: %w", err)
+	}
+	sort.Strings(images)
+
+	report := &ScanReport{MinSeverity: opts.MinSeverity, Totals: map[string]int{}}
+	findings := map[string]map[string]int{}
+	for _, image := range images {
+		targetImage, repoName, err := ecrClient.ConvertImageName(image, opts.RepositoryPrefix)
+		if err != nil {
+			logger.Warn("could not map image to ECR", "image", image, "error", err)
+			continue
+		}
+		tag := targetImage[strings.LastIndex(targetImage, ":")+1:]
+
+		scan, err := ecrClient.GetScanFindings(ctx, repoName, tag, opts.platforms)
+		if err != nil {
+			return nil, err
+		}
+		scan.Counts = filterSeverities(scan.Counts, opts.MinSeverity)
+		findings[image] = scan.Counts
+		report.Images = append(report.Images, ImageScan{Image: image, ScanFindings: *scan})
+		addCounts(report.Totals, scan.Counts)
+	}
+
+	type workloadKey struct{ namespace, kind, name string }
+	workloads := map[workloadKey]*WorkloadScan{}
+	for _, info := range infos {
+		counts, ok := findings[info.Image]
+		if !ok {
+			continue
+		}
+		key := workloadKey{info.Namespace, info.SourceKind, info.SourceName}
+		w := workloads[key]
+		if w == nil {
+			w = &WorkloadScan{Namespace: info.Namespace, Kind: info.SourceKind, Name: info.SourceName, Counts: map[string]int{}}
+			workloads[key] = w
+		}
+		if containsString(w.Images, info.Image) {
+			continue
+		}
+		w.Images = append(w.Images, info.Image)
+		addCounts(w.Counts, counts)
+	}
+
+	namespaces := map[string]*NamespaceScan{}
+	namespaceImages := map[string]map[string]bool{}
+	for _, w := range workloads {
+		report.Workloads = append(report.Workloads, *w)
+		ns := namespaces[w.Namespace]
+		if ns == nil {
+			ns = &NamespaceScan{Namespace: w.Namespace, Counts: map[string]int{}}
+			namespaces[w.Namespace] = ns
+			namespaceImages[w.Namespace] = map[string]bool{}
+		}
+		ns.Workloads++
+		for _, image := range w.Images {
+			if namespaceImages[w.Namespace][image] {
+				continue
+			}
+			namespaceImages[w.Namespace][image] = true
+			ns.Images++
+			addCounts(ns.Counts, findings[image])
+		}
+	}
+	for _, ns := range namespaces {
+		report.Namespaces = append(report.Namespaces, *ns)
+	}
+
+	sort.Slice(report.Workloads, func(i, j int) bool {
+		a, b := report.Workloads[i], report.Workloads[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	sort.Slice(report.Namespaces, func(i, j int) bool { return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace })
+	return report, nil
+}
+
+// reportedSeverities returns the severities shown for the given minimum severity.
+func reportedSeverities(minSeverity string) []string {
+	if minSeverity == "" {
+		return ecr.Severities
+	}
+	var out []string
+	for _, s := range ecr.Severities {
+		if ecr.SeverityRank(s) >= ecr.SeverityRank(minSeverity) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// filterSeverities drops counts below the minimum severity.
+func filterSeverities(counts map[string]int, minSeverity string) map[string]int {
+	out := map[string]int{}
+	for _, s := range reportedSeverities(minSeverity) {
+		if counts[s] > 0 {
+			out[s] = counts[s]
+		}
+	}
+	return out
+}
+
+// addCounts adds the severity counts of src to dst.
+func addCounts(dst, src map[string]int) {
+	for s, n := range src {
+		dst[s] += n
+	}
+}
+
+// sumCounts returns the total number of findings in counts.
+func sumCounts(counts map[string]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// containsString reports whether s is in list.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// printScanReportTable prints per-workload and per-namespace severity counts as tables.
+func printScanReportTable(report *ScanReport) {
+	severities := reportedSeverities(report.MinSeverity)
+
+	fmt.Println("WORKLOADS:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "NAMESPACE\tKIND\tNAME\tIMAGES\t%s\n", strings.Join(severities, "\t"))
+	for _, wl := range report.Workloads {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", wl.Namespace, wl.Kind, wl.Name, len(wl.Images), joinCounts(wl.Counts, severities, "\t"))
+	}
+	w.Flush()
+
+	fmt.Println("\nNAMESPACES:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "NAMESPACE\tWORKLOADS\tIMAGES\t%s\n", strings.Join(severities, "\t"))
+	for _, ns := range report.Namespaces {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", ns.Namespace, ns.Workloads, ns.Images, joinCounts(ns.Counts, severities, "\t"))
+	}
+	w.Flush()
+
+	var unscanned []string
+	for _, img := range report.Images {
+		if img.Status != "COMPLETE" && img.Status != "ACTIVE" {
+			unscanned = append(unscanned, fmt.Sprintf("%s (%s)", img.Image, img.Status))
+		}
+	}
+	if len(unscanned) > 0 {
+		fmt.Printf("\n⚠️ %d images without completed scan results:\n", len(unscanned))
+		for _, u := range unscanned {
+			fmt.Printf("   - %s\n", u)
+		}
+	}
+
+	fmt.Printf("\nTotal: %d images, %d findings (%s)\n", len(report.Images), sumCounts(report.Totals), formatCounts(report.Totals, severities))
+}
+
+// printScanReportCSV prints workload and namespace rows as CSV.
+func printScanReportCSV(report *ScanReport) error {
+	severities := reportedSeverities(report.MinSeverity)
+	w := csv.NewWriter(os.Stdout)
+
+	header := append([]string{"scope", "namespace", "kind", "name", "images"}, severities...)
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, wl := range report.Workloads {
+		row := []string{"workload", wl.Namespace, wl.Kind, wl.Name, strconv.Itoa(len(wl.Images))}
+		if err := w.Write(append(row, strings.Split(joinCounts(wl.Counts, severities, ","), ",")...)); err != nil {
+			return err
+		}
+	}
+	for _, ns := range report.Namespaces {
+		row := []string{"namespace", ns.Namespace, "", "", strconv.Itoa(ns.Images)}
+		if err := w.Write(append(row, strings.Split(joinCounts(ns.Counts, severities, ","), ",")...)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// joinCounts joins the counts of the given severities with sep.
+func joinCounts(counts map[string]int, severities []string, sep string) string {
+	parts := make([]string, 0, len(severities))
+	for _, s := range severities {
+		parts = append(parts, strconv.Itoa(counts[s]))
+	}
+	return strings.Join(parts, sep)
+}
+
+// formatCounts formats non-zero counts as "CRITICAL=1, HIGH=3".
+func formatCounts(counts map[string]int, severities []string) string {
+	var parts []string
+	for _, s := range severities {
+		if counts[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", s, counts[s]))
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
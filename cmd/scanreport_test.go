@@ -0,0 +1,268 @@
+/*
+Copyright © 2025 Krane CLI menbiyagoral@gmail.com
+*/
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"krane/pkg/ecr"
+	"krane/pkg/k8s"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsecr "github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+)
+
+const (
+	ociIndex    = "application/vnd.oci.image.index.v1+json"
+	ociManifest = "application/vnd.oci.image.manifest.v1+json"
+)
+
+// scanStub answers DescribeImageScanFindings from a map keyed by "repository:tag" or
+// "repository@digest", and serves the indexes in indexes, keyed by "repository:tag", as
+// multi-platform images. Calls to any other ECR API method panic through the nil embedded interface.
+type scanStub struct {
+	ecr.API
+	scans   map[string]*awsecr.DescribeImageScanFindingsOutput
+	indexes map[string]string
+}
+
+// stubDigest returns a fake digest of key.
+func stubDigest(key string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(key)))
+}
+
+func (s *scanStub) DescribeImages(ctx context.Context, params *awsecr.DescribeImagesInput, optFns ...func(*awsecr.Options)) (*awsecr.DescribeImagesOutput, error) {
+	key := aws.ToString(params.RepositoryName) + ":" + aws.ToString(params.ImageIds[0].ImageTag)
+	if _, ok := s.indexes[key]; ok {
+		return &awsecr.DescribeImagesOutput{ImageDetails: []ecrtypes.ImageDetail{{ImageDigest: aws.String(stubDigest(key)), ImageManifestMediaType: aws.String(ociIndex)}}}, nil
+	}
+	if _, ok := s.scans[key]; ok {
+		return &awsecr.DescribeImagesOutput{ImageDetails: []ecrtypes.ImageDetail{{ImageDigest: aws.String(stubDigest(key)), ImageManifestMediaType: aws.String(ociManifest)}}}, nil
+	}
+	return nil, &ecrtypes.ImageNotFoundException{Message: aws.String("no image " + key)}
+}
+
+func (s *scanStub) BatchGetImage(ctx context.Context, params *awsecr.BatchGetImageInput, optFns ...func(*awsecr.Options)) (*awsecr.BatchGetImageOutput, error) {
+	digest := aws.ToString(params.ImageIds[0].ImageDigest)
+	for key, manifest := range s.indexes {
+		if stubDigest(key) == digest {
+			return &awsecr.BatchGetImageOutput{Images: []ecrtypes.Image{{ImageId: &params.ImageIds[0], ImageManifest: aws.String(manifest)}}}, nil
+		}
+	}
+	return &awsecr.BatchGetImageOutput{}, nil
+}
+
+func (s *scanStub) DescribeImageScanFindings(ctx context.Context, params *awsecr.DescribeImageScanFindingsInput, optFns ...func(*awsecr.Options)) (*awsecr.DescribeImageScanFindingsOutput, error) {
+	key := aws.ToString(params.RepositoryName) + ":" + aws.ToString(params.ImageId.ImageTag)
+	if params.ImageId.ImageDigest != nil {
+		key = aws.ToString(params.RepositoryName) + "@" + aws.ToString(params.ImageId.ImageDigest)
+	}
+	if _, ok := s.indexes[key]; ok {
+		// ECR keeps no findings for the index itself
+		return nil, &ecrtypes.ScanNotFoundException{Message: aws.String("no scan " + key)}
+	}
+	out, ok := s.scans[key]
+	if !ok {
+		return nil, &ecrtypes.ImageNotFoundException{Message: aws.String("no image " + key)}
+	}
+	return out, nil
+}
+
+// scanOutput returns a scan result with the given status and severity counts.
+func scanOutput(status ecrtypes.ScanStatus, counts map[string]int32) *awsecr.DescribeImageScanFindingsOutput {
+	out := &awsecr.DescribeImageScanFindingsOutput{ImageScanStatus: &ecrtypes.ImageScanStatus{Status: status}}
+	if counts != nil {
+		out.ImageScanFindings = &ecrtypes.ImageScanFindings{FindingSeverityCounts: counts}
+	}
+	return out
+}
+
+func TestBuildScanReport(t *testing.T) {
+	stub := &scanStub{scans: map[string]*awsecr.DescribeImageScanFindingsOutput{
+		"krane/nginx:1.27":   scanOutput(ecrtypes.ScanStatusComplete, map[string]int32{"CRITICAL": 1, "HIGH": 2, "LOW": 4}),
+		"krane/redis:7":      scanOutput(ecrtypes.ScanStatusComplete, map[string]int32{"HIGH": 1, "MEDIUM": 3}),
+		"krane/busybox:1.37": scanOutput(ecrtypes.ScanStatusInProgress, nil),
+		"krane/postgres:16":  scanOutput(ecrtypes.ScanStatusFailed, nil),
+	}}
+	client := ecr.NewClientFromAPI(stub, "eu-west-1", "123456789012")
+
+	infos := []k8s.ImageInfo{
+		// The same image in two pods of one workload is counted once
+		{Image: "nginx:1.27", Namespace: "web", SourceKind: "Deployment", SourceName: "frontend", PodName: "frontend-1"},
+		{Image: "nginx:1.27", Namespace: "web", SourceKind: "Deployment", SourceName: "frontend", PodName: "frontend-2"},
+		{Image: "redis:7", Namespace: "web", SourceKind: "Deployment", SourceName: "frontend", PodName: "frontend-1"},
+		// The same image in two workloads of one namespace is counted once for the namespace
+		{Image: "redis:7", Namespace: "web", SourceKind: "StatefulSet", SourceName: "cache", PodName: "cache-0"},
+		{Image: "busybox:1.37", Namespace: "jobs", SourceKind: "Job", SourceName: "migrate", PodName: "migrate-x"},
+		{Image: "postgres:16", Namespace: "jobs", SourceKind: "StatefulSet", SourceName: "db", PodName: "db-0"},
+		{Image: "unscanned:1.0.0", Namespace: "jobs", SourceKind: "Job", SourceName: "migrate", PodName: "migrate-x"},
+	}
+
+	t.Run("all severities", func(t *testing.T) {
+		report, err := buildScanReport(context.Background(), client, infos, &ScanReportOptions{RepositoryPrefix: "krane"})
+		if err != nil {
+			t.Fatalf("buildScanReport: %v", err)
+		}
+
+		statuses := map[string]string{}
+		for _, img := range report.Images {
+			statuses[img.Image] = img.Status
+		}
+		wantStatuses := map[string]string{
+			"busybox:1.37":    "IN_PROGRESS",
+			"nginx:1.27":      "COMPLETE",
+			"postgres:16":     "FAILED",
+			"redis:7":         "COMPLETE",
+			"unscanned:1.0.0": ecr.ScanStatusNotMirrored,
+		}
+		if !reflect.DeepEqual(statuses, wantStatuses) {
+			t.Errorf("image statuses = %v, want %v", statuses, wantStatuses)
+		}
+
+		wantTotals := map[string]int{"CRITICAL": 1, "HIGH": 3, "MEDIUM": 3, "LOW": 4}
+		if !reflect.DeepEqual(report.Totals, wantTotals) {
+			t.Errorf("totals = %v, want %v", report.Totals, wantTotals)
+		}
+
+		wantWorkloads := []string{
+			"jobs/Job/migrate [busybox:1.37 unscanned:1.0.0] map[]",
+			"jobs/StatefulSet/db [postgres:16] map[]",
+			"web/Deployment/frontend [nginx:1.27 redis:7] map[CRITICAL:1 HIGH:3 LOW:4 MEDIUM:3]",
+			"web/StatefulSet/cache [redis:7] map[HIGH:1 MEDIUM:3]",
+		}
+		var workloads []string
+		for _, w := range report.Workloads {
+			workloads = append(workloads, fmt.Sprintf("%s/%s/%s %v %v", w.Namespace, w.Kind, w.Name, w.Images, w.Counts))
+		}
+		if !reflect.DeepEqual(workloads, wantWorkloads) {
+			t.Errorf("workloads = %q, want %q", workloads, wantWorkloads)
+		}
+
+		wantNamespaces := []NamespaceScan{
+			{Namespace: "jobs", Workloads: 2, Images: 3, Counts: map[string]int{}},
+			{Namespace: "web", Workloads: 2, Images: 2, Counts: map[string]int{"CRITICAL": 1, "HIGH": 3, "MEDIUM": 3, "LOW": 4}},
+		}
+		if !reflect.DeepEqual(report.Namespaces, wantNamespaces) {
+			t.Errorf("namespaces = %+v, want %+v", report.Namespaces, wantNamespaces)
+		}
+	})
+
+	t.Run("min severity", func(t *testing.T) {
+		report, err := buildScanReport(context.Background(), client, infos, &ScanReportOptions{RepositoryPrefix: "krane", MinSeverity: "HIGH"})
+		if err != nil {
+			t.Fatalf("buildScanReport: %v", err)
+		}
+		wantTotals := map[string]int{"CRITICAL": 1, "HIGH": 3}
+		if !reflect.DeepEqual(report.Totals, wantTotals) {
+			t.Errorf("totals = %v, want %v", report.Totals, wantTotals)
+		}
+		if len(report.Images) != 5 {
+			t.Errorf("got %d images, want images without findings to stay in the report", len(report.Images))
+		}
+	})
+
+	t.Run("exclude patterns", func(t *testing.T) {
+		report, err := buildScanReport(context.Background(), client, infos, &ScanReportOptions{RepositoryPrefix: "krane", ExcludePatterns: []string{"redis"}})
+		if err != nil {
+			t.Fatalf("buildScanReport: %v", err)
+		}
+		for _, w := range report.Workloads {
+			if w.Name == "cache" {
+				t.Errorf("workload cache only runs excluded images but is reported: %+v", w)
+			}
+		}
+		wantTotals := map[string]int{"CRITICAL": 1, "HIGH": 2, "LOW": 4}
+		if !reflect.DeepEqual(report.Totals, wantTotals) {
+			t.Errorf("totals = %v, want %v", report.Totals, wantTotals)
+		}
+	})
+}
+
+// platformIndex returns an OCI index listing the given digests for linux/amd64 and
+// linux/arm64, followed by an attestation manifest.
+func platformIndex(amd64, arm64 string) string {
+	return fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,"manifests":[
+		{"mediaType":%q,"digest":%q,"size":1,"platform":{"os":"linux","architecture":"amd64"}},
+		{"mediaType":%q,"digest":%q,"size":1,"platform":{"os":"linux","architecture":"arm64"}},
+		{"mediaType":%q,"digest":%q,"size":1,"platform":{"os":"unknown","architecture":"unknown"}}]}`,
+		ociIndex, ociManifest, amd64, ociManifest, arm64, ociManifest, stubDigest("attestation"))
+}
+
+func TestBuildScanReportMultiPlatform(t *testing.T) {
+	appAMD64, appARM64 := stubDigest("app-amd64"), stubDigest("app-arm64")
+	webAMD64, webARM64 := stubDigest("web-amd64"), stubDigest("web-arm64")
+	stub := &scanStub{
+		indexes: map[string]string{
+			"krane/app:2": platformIndex(appAMD64, appARM64),
+			"krane/web:1": platformIndex(webAMD64, webARM64),
+		},
+		scans: map[string]*awsecr.DescribeImageScanFindingsOutput{
+			"krane/app@" + appAMD64: scanOutput(ecrtypes.ScanStatusComplete, map[string]int32{"HIGH": 2}),
+			"krane/app@" + appARM64: scanOutput(ecrtypes.ScanStatusComplete, map[string]int32{"CRITICAL": 1, "HIGH": 1}),
+			"krane/web@" + webAMD64: scanOutput(ecrtypes.ScanStatusComplete, map[string]int32{"LOW": 1}),
+			"krane/web@" + webARM64: scanOutput(ecrtypes.ScanStatusInProgress, nil),
+		},
+	}
+	client := ecr.NewClientFromAPI(stub, "eu-west-1", "123456789012")
+	infos := []k8s.ImageInfo{
+		{Image: "app:2", Namespace: "web", SourceKind: "Deployment", SourceName: "app", PodName: "app-1"},
+		{Image: "web:1", Namespace: "web", SourceKind: "Deployment", SourceName: "web", PodName: "web-1"},
+	}
+
+	tests := []struct {
+		name      string
+		platform  string
+		app       ecr.ScanFindings
+		webStatus string
+	}{
+		{
+			name:      "all platforms",
+			app:       ecr.ScanFindings{Repository: "krane/app", Tag: "2", Status: "COMPLETE", Counts: map[string]int{"CRITICAL": 1, "HIGH": 3}, Platforms: []string{"linux/amd64", "linux/arm64"}},
+			webStatus: "IN_PROGRESS",
+		},
+		{
+			name:      "one platform",
+			platform:  "linux/amd64",
+			app:       ecr.ScanFindings{Repository: "krane/app", Tag: "2", Status: "COMPLETE", Counts: map[string]int{"HIGH": 2}, Platforms: []string{"linux/amd64"}},
+			webStatus: "COMPLETE",
+		},
+		{
+			name:      "no matching platform",
+			platform:  "windows/amd64",
+			app:       ecr.ScanFindings{Repository: "krane/app", Tag: "2", Status: ecr.ScanStatusNoPlatform, Counts: map[string]int{}},
+			webStatus: ecr.ScanStatusNoPlatform,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &ScanReportOptions{Format: "table", RepositoryPrefix: "krane", Platform: tt.platform}
+			if err := opts.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			report, err := buildScanReport(context.Background(), client, infos, opts)
+			if err != nil {
+				t.Fatalf("buildScanReport: %v", err)
+			}
+			if len(report.Images) != 2 {
+				t.Fatalf("got %d images, want 2", len(report.Images))
+			}
+			if got := report.Images[0].ScanFindings; !reflect.DeepEqual(got, tt.app) {
+				t.Errorf("app findings = %+v, want %+v", got, tt.app)
+			}
+			if got := report.Images[1].Status; got != tt.webStatus {
+				t.Errorf("web status = %s, want %s", got, tt.webStatus)
+			}
+		})
+	}
+
+	opts := &ScanReportOptions{Format: "table", Platform: "linux"}
+	if err := opts.Validate(); err == nil {
+		t.Error("Validate accepted a platform without architecture")
+	}
+}
@@ -0,0 +1,439 @@
+/*
+Copyright © 2025 Krane CLI menbiyagoral@gmail.com
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"krane/pkg/ecr"
+	"krane/pkg/tracing"
+	"krane/pkg/transfer"
+	"krane/pkg/utils"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+	"gopkg.in/yaml.v3"
+)
+
+// Sync policies for upstream tags that point to a new digest.
+const (
+	syncPolicyReport = "report"
+	syncPolicyUpdate = "update"
+	syncPolicyNewTag = "new-tag"
+)
+
+// Sync statuses of a mirrored tag.
+const (
+	syncStatusCurrent     = "current"     // the mirror holds what the upstream tag points to
+	syncStatusSnapshotted = "snapshotted" // the mirror tag is stale; a --tag-format tag holds the upstream content
+	syncStatusMoved       = "moved"       // the upstream tag moved and nothing was written
+	syncStatusMissing     = "missing"     // the tag is not mirrored and nothing was written
+	syncStatusPinned      = "pinned"      // digest references cannot move
+	syncStatusUpdated     = "updated"     // the mirror tag was overwritten
+	syncStatusTagged      = "tagged"      // the new content was written under a new tag
+	syncStatusMirrored    = "mirrored"    // the missing tag was written
+	syncStatusFailed      = "failed"
+)
+
+// tagPattern is the syntax of OCI tags.
+var tagPattern = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}$`)
+
+// SyncOptions holds flag values for the sync command.
+type SyncOptions struct {
+	PushOptions
+	Images    []string
+	Policy    string
+	TagFormat string
+	Format    string
+}
+
+// Validate validates sync command options and returns error if invalid.
+func (opts *SyncOptions) Validate() error {
+	switch opts.Policy {
+	case syncPolicyReport, syncPolicyUpdate, syncPolicyNewTag:
+	default:
+		return fmt.Errorf("invalid policy: %s (valid: report, update, new-tag)", opts.Policy)
+	}
+	validFormats := map[string]bool{"table": true, "json": true, "yaml": true}
+	if !validFormats[opts.Format] {
+		return fmt.Errorf("invalid format: %s (valid: table, json, yaml)", opts.Format)
+	}
+	if !strings.Contains(opts.TagFormat, "{date}") && !strings.Contains(opts.TagFormat, "{digest}") {
+		return fmt.Errorf("tag-format must contain {date} or {digest}, got: %s", opts.TagFormat)
+	}
+	if sample := expandTagFormat(opts.TagFormat, "1.0.0", time.Now(), strings.Repeat("0", 12)); !tagPattern.MatchString(sample) {
+		return fmt.Errorf("tag-format does not produce valid tags, e.g. %q", sample)
+	}
+	if len(opts.Images) > 0 && opts.Platform == "auto" {
+		return fmt.Errorf("--platform auto needs a cluster and cannot be used with image arguments")
+	}
+	return nil
+}
+
+// newSyncCmd constructs the sync command with its own options.
+func newSyncCmd() *cobra.Command {
+	opts := &SyncOptions{
+		PushOptions: PushOptions{Mode: "copy", Progress: progressNone, ProgressInterval: time.Second},
+		Format:      "table",
+	}
+	cmd := &cobra.Command{
+		Use:   "sync [image]...",
+		Short: "Detect upstream tags that moved since they were mirrored to AWS ECR",
+		Long: `Check whether the upstream tags of mirrored images now point to a different digest
+than their copies in ECR, and propagate the change according to --policy:
+
+  report   only report moved and missing tags (default)
+  update   overwrite the ECR tag with the new content
+  new-tag  keep the ECR tag and write the new content under --tag-format,
+           e.g. 1.25-20250301, leaving existing tags immutable
+
+Images are those running in the cluster, selected as by push, or the given image
+arguments. They are mapped to ECR with the same naming rules as push. Copies
+filtered with --platform are current as long as their manifests are still part
+of the upstream index. Images referenced by digest cannot move and are skipped.
+
+Examples:
+  krane sync -A -r eu-west-1                        # Report moved tags
+  krane sync -A -r eu-west-1 --policy update        # Follow upstream patches
+  krane sync nginx:1.27 --policy new-tag -o json    # Snapshot a moved tag`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Namespace = globalNamespace
+			opts.AllNamespaces = globalAllNamespaces
+			opts.Regions = utils.RemoveDuplicates(globalRegions)
+			opts.Images = args
+			if globalOutput != "" {
+				opts.Format = globalOutput
+			}
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			if err := opts.ValidateWithCmd(cmd); err != nil {
+				return err
+			}
+			return runSync(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Policy, "policy", syncPolicyReport, "What to do with moved tags: report, update (overwrite the ECR tag) or new-tag (write under --tag-format)")
+	cmd.Flags().StringVar(&opts.TagFormat, "tag-format", "{tag}-{date}", "Tag for new content with --policy new-tag; {tag}, {date} (YYYYMMDD, UTC) and {digest} (12 hex digits) are replaced")
+	addDiscoveryFlags(cmd, &opts.PushOptions)
+	addTransferFlags(cmd, &opts.PushOptions)
+
+	return cmd
+}
+
+// SyncResult is the outcome of one image in one destination registry.
+type SyncResult struct {
+	Image        string `json:"image" yaml:"image"`
+	Target       string `json:"target" yaml:"target"`
+	Status       string `json:"status" yaml:"status"`
+	SourceDigest string `json:"sourceDigest,omitempty" yaml:"sourceDigest,omitempty"`
+	MirrorDigest string `json:"mirrorDigest,omitempty" yaml:"mirrorDigest,omitempty"`
+	// Written is the reference written, or that would be written in a dry run.
+	Written string `json:"written,omitempty" yaml:"written,omitempty"`
+	// Snapshot is the existing tag already holding the new content with --policy new-tag.
+	Snapshot string `json:"snapshot,omitempty" yaml:"snapshot,omitempty"`
+	Error    string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// SyncReport represents the structure for JSON/YAML sync output.
+type SyncReport struct {
+	Policy  string         `json:"policy" yaml:"policy"`
+	DryRun  bool           `json:"dryRun" yaml:"dryRun"`
+	Counts  map[string]int `json:"counts" yaml:"counts"`
+	Results []SyncResult   `json:"results" yaml:"results"`
+}
+
+// runSync executes the sync command with the given options.
+func runSync(ctx context.Context, opts *SyncOptions) error {
+	if err := opts.setupTransfer(); err != nil {
+		return err
+	}
+
+	phaseCtx, span := tracing.Start(ctx, "create ECR clients")
+	destinations, err := newDestinations(phaseCtx, &opts.PushOptions)
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+
+	images := utils.RemoveDuplicates(opts.Images)
+	if len(images) == 0 {
+		phaseCtx, span = tracing.Start(ctx, "discover images")
+		images, err = discoverPushImages(phaseCtx, &opts.PushOptions)
+		span.SetAttributes(attribute.Int("krane.images", len(images)))
+		tracing.End(span, err)
+		if err != nil {
+			return err
+		}
+	}
+	kraneMetrics.ImagesDiscovered.Add(float64(len(images)))
+	logger.Info("checking mirrored tags", "images", len(images), "registries", len(destinations), "policy", opts.Policy)
+
+	phaseCtx, span = tracing.Start(ctx, "authenticate")
+	keychain, err := authenticateDestinations(phaseCtx, destinations)
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+
+	report := SyncReport{Policy: opts.Policy, DryRun: opts.DryRun, Counts: map[string]int{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.MaxConcurrent)
+	for _, image := range images {
+		wg.Add(1)
+		go func(image string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			for _, ecrClient := range destinations {
+				result := syncImage(ctx, ecrClient, keychain, image, opts)
+				mu.Lock()
+				report.Results = append(report.Results, result)
+				report.Counts[result.Status]++
+				mu.Unlock()
+			}
+		}(image)
+	}
+	wg.Wait()
+	sort.Slice(report.Results, func(i, j int) bool {
+		if report.Results[i].Image != report.Results[j].Image {
+			return report.Results[i].Image < report.Results[j].Image
+		}
+		return report.Results[i].Target < report.Results[j].Target
+	})
+
+	switch opts.Format {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	case "yaml":
+		data, err := yaml.Marshal(report)
+		if err != nil {
+			return fmt.Errorf("marshaling YAML: %w", err)
+		}
+		fmt.Println(string(data))
+	default:
+		printSyncTable(report)
+	}
+
+	counts := make([]any, 0, 2*len(report.Counts))
+	for _, status := range []string{syncStatusCurrent, syncStatusSnapshotted, syncStatusMoved, syncStatusMissing, syncStatusPinned,
+		syncStatusUpdated, syncStatusTagged, syncStatusMirrored, syncStatusFailed} {
+		counts = append(counts, status, report.Counts[status])
+	}
+	logger.Info("sync summary", counts...)
+	if n := report.Counts[syncStatusFailed]; n > 0 {
+		return fmt.Errorf("%d tags failed to sync", n)
+	}
+	return nil
+}
+
+// syncImage checks one image against its mirror in one registry and applies the policy.
+func syncImage(ctx context.Context, ecrClient *ecr.Client, keychain authn.Keychain, image string, opts *SyncOptions) (result SyncResult) {
+	ctx, span := tracing.Start(ctx, "sync image",
+		attribute.String("krane.image", image), attribute.String("krane.registry", ecrClient.GetRegistryURL()))
+	result = SyncResult{Image: image}
+	defer func() {
+		span.SetAttributes(attribute.String("krane.status", result.Status))
+		if result.Status == syncStatusFailed {
+			logger.Error("sync failed", "image", image, "registry", ecrClient.GetRegistryURL(), "error", result.Error)
+		}
+		span.End()
+	}()
+	fail := func(err error) SyncResult {
+		result.Status = syncStatusFailed
+		result.Error = err.Error()
+		kraneMetrics.ImagesFailed.WithLabelValues(failureReason(JobResult{Error: err})).Inc()
+		return result
+	}
+
+	targetImage, repoName, err := ecrClient.ConvertImageName(image, opts.RepositoryPrefix)
+	if err != nil {
+		return fail(err)
+	}
+	result.Target = targetImage
+	if strings.Contains(image, "@") {
+		result.Status = syncStatusPinned
+		return result
+	}
+	_, tag := splitImageTag(targetImage)
+
+	sourceOpts := transfer.Options{
+		Platforms:           opts.platforms,
+		SourceKeychain:      opts.sourceKeychains[image],
+		DestinationKeychain: keychain,
+		Logger:              logger,
+		Transport:           opts.transport,
+		BlobCache:           opts.blobCache,
+		Mounts:              opts.mounts,
+		Registries:          opts.registries,
+	}
+
+	exists, err := ecrClient.ImageTagExists(ctx, repoName, tag)
+	if err != nil {
+		return fail(fmt.Errorf("could not check existing tag for %s:%s: %w", repoName, tag, err))
+	}
+	var write, written string
+	if !exists {
+		result.Status = syncStatusMissing
+		if opts.Policy != syncPolicyReport {
+			write, written = targetImage, syncStatusMirrored
+		}
+	} else {
+		state, err := transfer.CheckTag(ctx, image, targetImage, sourceOpts)
+		if err != nil {
+			return fail(err)
+		}
+		result.SourceDigest, result.MirrorDigest = state.SourceDigest.String(), state.MirrorDigest.String()
+		if state.Current {
+			result.Status = syncStatusCurrent
+			return result
+		}
+		result.Status = syncStatusMoved
+		logger.Info("upstream tag moved", "image", image, "target", targetImage,
+			"sourceDigest", result.SourceDigest, "mirrorDigest", result.MirrorDigest)
+
+		switch opts.Policy {
+		case syncPolicyUpdate:
+			write, written = targetImage, syncStatusUpdated
+		case syncPolicyNewTag:
+			newTag, snapshot, err := newSnapshotTag(ctx, ecrClient, repoName, image, tag, state, sourceOpts, opts.TagFormat)
+			if err != nil {
+				return fail(err)
+			}
+			if snapshot != "" {
+				result.Snapshot = snapshot
+				result.Status = syncStatusSnapshotted
+				return result
+			}
+			write, written = strings.TrimSuffix(targetImage, ":"+tag)+":"+newTag, syncStatusTagged
+		}
+	}
+	if write == "" {
+		return result
+	}
+	result.Written = write
+	if opts.DryRun {
+		return result
+	}
+
+	if err := ecrClient.CreateRepository(ctx, repoName); err != nil {
+		return fail(fmt.Errorf("failed to create repository %s: %w", repoName, err))
+	}
+	copyCtx, cancel := deadlineContext(ctx, opts.Timeout)
+	defer cancel()
+	progress := &transfer.Progress{}
+	sourceOpts.Progress = progress
+	if err := transfer.Mirror(copyCtx, image, []string{write}, sourceOpts); err != nil {
+		return fail(fmt.Errorf("mirror failed %s: %w", image, err))
+	}
+	stats := progress.Snapshot()
+	kraneMetrics.ImagesCopied.Inc()
+	kraneMetrics.CopyDuration.Observe(stats.Elapsed.Seconds())
+	kraneMetrics.BytesTransferred.Add(float64(stats.BytesDone))
+	logger.Info("synced tag", append([]any{"image", image, "target", write, "status", written}, transferStatsAttrs(stats)...)...)
+	result.Status = written
+	return result
+}
+
+// newSnapshotTag returns the tag to write moved content under with --policy new-tag. When
+// the newest existing tag of that format already holds the upstream content, or the new
+// tag exists and does, it is returned as snapshot instead and nothing needs to be written.
+func newSnapshotTag(ctx context.Context, ecrClient *ecr.Client, repoName, image, tag string, state transfer.TagState, opts transfer.Options, format string) (string, string, error) {
+	newTag := expandTagFormat(format, tag, time.Now(), state.SourceDigest.Hex)
+	mirror := ecrClient.GetRegistryURL() + "/" + repoName + ":"
+
+	pattern := regexp.MustCompile("^" + strings.NewReplacer(
+		regexp.QuoteMeta("{tag}"), regexp.QuoteMeta(tag),
+		regexp.QuoteMeta("{date}"), "[0-9]{8}",
+		regexp.QuoteMeta("{digest}"), "[0-9a-f]{12}",
+	).Replace(regexp.QuoteMeta(format)) + "$")
+	images, err := ecrClient.ListImages(ctx, repoName)
+	if err != nil {
+		return "", "", err
+	}
+	var latest string
+	var latestPushed time.Time
+	for _, img := range images {
+		for _, t := range img.Tags {
+			if (pattern.MatchString(t) || t == newTag) && img.PushedAt.After(latestPushed) {
+				latest, latestPushed = t, img.PushedAt
+			}
+		}
+	}
+	if latest != "" {
+		snapshot, err := transfer.CheckTag(ctx, image, mirror+latest, opts)
+		if err != nil {
+			return "", "", err
+		}
+		if snapshot.Current {
+			return "", latest, nil
+		}
+	}
+
+	exists, err := ecrClient.ImageTagExists(ctx, repoName, newTag)
+	if err != nil {
+		return "", "", fmt.Errorf("could not check existing tag for %s:%s: %w", repoName, newTag, err)
+	}
+	if exists && newTag != latest {
+		snapshot, err := transfer.CheckTag(ctx, image, mirror+newTag, opts)
+		if err != nil {
+			return "", "", err
+		}
+		if snapshot.Current {
+			return "", newTag, nil
+		}
+	}
+	if exists {
+		return "", "", fmt.Errorf("tag %s already exists in %s with other content; add {digest} to --tag-format", newTag, repoName)
+	}
+	return newTag, "", nil
+}
+
+// expandTagFormat replaces {tag}, {date} and {digest} in a --tag-format.
+func expandTagFormat(format, tag string, now time.Time, digestHex string) string {
+	if len(digestHex) > 12 {
+		digestHex = digestHex[:12]
+	}
+	return strings.NewReplacer(
+		"{tag}", tag,
+		"{date}", now.UTC().Format("20060102"),
+		"{digest}", digestHex,
+	).Replace(format)
+}
+
+// printSyncTable prints the sync results as a table.
+func printSyncTable(report SyncReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tTARGET\tSTATUS\tUPSTREAM\tMIRROR\tWRITTEN")
+	for _, r := range report.Results {
+		written := r.Written
+		switch {
+		case r.Snapshot != "":
+			written = "<stale, upstream content in " + r.Snapshot + ">"
+		case r.Written != "" && report.DryRun:
+			written += " (dry run)"
+		case r.Error != "":
+			written = r.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Image, r.Target, r.Status, shortDigest(r.SourceDigest), shortDigest(r.MirrorDigest), written)
+	}
+	w.Flush()
+}
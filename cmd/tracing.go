@@ -0,0 +1,64 @@
+/*
+Copyright © 2025 Krane CLI menbiyagoral@gmail.com
+*/
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"krane/pkg/tracing"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingFlushTimeout bounds the export of pending spans when the command ends.
+const tracingFlushTimeout = 10 * time.Second
+
+var (
+	// shutdownTracing flushes pending spans; it is replaced when tracing is set up.
+	shutdownTracing = func(context.Context) error { return nil }
+	// commandSpan is the root span of the running command.
+	commandSpan trace.Span
+)
+
+// instrument returns a wrapper adding tracing and metrics to a transport used for service.
+func instrument(service string) func(http.RoundTripper) http.RoundTripper {
+	wrapMetrics := kraneMetrics.WrapTransport(service)
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return wrapMetrics(tracing.WrapTransport(rt))
+	}
+}
+
+// startTracing sets up span export from the global tracing flags and starts the root
+// span of cmd, whose context is passed on to the command.
+func startTracing(cmd *cobra.Command) error {
+	shutdown, err := tracing.Setup(cmd.Context(), tracing.Options{
+		Endpoint: globalOTLPEndpoint,
+		Insecure: globalOTLPInsecure,
+	})
+	if err != nil {
+		return err
+	}
+	shutdownTracing = shutdown
+
+	ctx, span := tracing.Start(cmd.Context(), cmd.CommandPath(), attribute.StringSlice("krane.args", cmd.Flags().Args()))
+	commandSpan = span
+	cmd.SetContext(ctx)
+	return nil
+}
+
+// stopTracing ends the command span with the command's error and flushes pending spans.
+func stopTracing(err error) {
+	if commandSpan != nil {
+		tracing.End(commandSpan, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("failed to export traces", "error", err)
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright © 2025 Krane CLI menbiyagoral@gmail.com
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"krane/pkg/k8s"
+	"krane/pkg/utils"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// WhoUsesOptions holds flag values for the who-uses command.
+type WhoUsesOptions struct {
+	AllNamespaces     bool
+	Namespace         string
+	Format            string
+	Patterns          []string
+	IncludeNamespaces []string
+	ExcludeNamespaces []string
+}
+
+// Validate validates who-uses command options and returns error if invalid.
+func (opts *WhoUsesOptions) Validate() error {
+	validFormats := map[string]bool{"table": true, "json": true, "yaml": true}
+	if !validFormats[opts.Format] {
+		return fmt.Errorf("invalid format: %s (valid: table, json, yaml)", opts.Format)
+	}
+	if len(opts.Patterns) == 0 {
+		return fmt.Errorf("at least one image pattern is required")
+	}
+	return nil
+}
+
+// newWhoUsesCmd constructs the who-uses command with its own options.
+func newWhoUsesCmd() *cobra.Command {
+	opts := &WhoUsesOptions{Format: "table"}
+	cmd := &cobra.Command{
+		Use:   "who-uses <pattern>...",
+		Short: "Show every workload, pod and container using matching images",
+		Long: `Find every namespace, owner chain, pod and container that uses an image
+matching one of the given patterns. Patterns are prefix matches by default;
+use re:, glob: or exact: for other kinds (e.g. "glob:*log4j*" for images that
+contain log4j anywhere), registry:, repository: or tag: to match a parsed
+component, i: to ignore case and a leading ! to negate.
+
+Init containers, native sidecars and ephemeral debug containers are included
+and labelled with their container type.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Namespace = globalNamespace
+			opts.AllNamespaces = globalAllNamespaces
+			if globalOutput != "" {
+				opts.Format = globalOutput
+			}
+			opts.Patterns = args
+			if err := opts.Validate(); err != nil {
+				return err
+			}
+			return runWhoUses(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&opts.IncludeNamespaces, "include-namespaces", nil, "Only include these namespaces "+namespacePatternHelp)
+	cmd.Flags().StringSliceVar(&opts.ExcludeNamespaces, "exclude-namespaces", nil, "Exclude these namespaces "+namespacePatternHelp)
+
+	return cmd
+}
+
+// ImageUsageList represents the structure for JSON/YAML who-uses output.
+type ImageUsageList struct {
+	Usages []k8s.ImageInfo `json:"usages" yaml:"usages"`
+	Images int             `json:"images" yaml:"images"`
+	Total  int             `json:"total" yaml:"total"`
+}
+
+// runWhoUses executes the who-uses command with the given options.
+func runWhoUses(ctx context.Context, opts *WhoUsesOptions) error {
+	client, err := k8s.NewClient("")
+	if err != nil {
+		return fmt.Errorf("creating Kubernetes client: %w", err)
+	}
+
+	effectiveAllNamespaces := opts.AllNamespaces
+	if strings.TrimSpace(opts.Namespace) == "" {
+		effectiveAllNamespaces = true
+	}
+
+	infos, err := k8s.ListPodImagesWithSource(ctx, client, k8s.DiscoveryOptions{
+		AllNamespaces:     effectiveAllNamespaces,
+		Namespace:         opts.Namespace,
+		IncludeNamespaces: opts.IncludeNamespaces,
+		ExcludeNamespaces: opts.ExcludeNamespaces,
+		IncludeEphemeral:  true,
+		Logger:            logger,
+	})
+	if err != nil {
+		return fmt.Errorf("listing pod images: %w", err)
+	}
+
+	var images []string
+	for _, info := range infos {
+		images = append(images, info.Image)
+	}
+	matched, err := utils.FilterImages(utils.RemoveDuplicates(images), opts.Patterns, nil)
+	if err != nil {
+		return fmt.Errorf("invalid image patterns: %w", err)
+	}
+	usages := filterImageInfos(infos, matched)
+	sort.SliceStable(usages, func(i, j int) bool {
+		a, b := usages[i], usages[j]
+		if a.Image != b.Image {
+			return a.Image < b.Image
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.PodName != b.PodName {
+			return a.PodName < b.PodName
+		}
+		return a.ContainerName < b.ContainerName
+	})
+
+	switch opts.Format {
+	case "json":
+		data, err := json.MarshalIndent(ImageUsageList{Usages: usages, Images: len(matched), Total: len(usages)}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	case "yaml":
+		data, err := yaml.Marshal(ImageUsageList{Usages: usages, Images: len(matched), Total: len(usages)})
+		if err != nil {
+			return fmt.Errorf("marshaling YAML: %w", err)
+		}
+		fmt.Println(string(data))
+	default:
+		printUsageTable(usages, len(matched))
+	}
+	return nil
+}
+
+// printUsageTable prints one row per container using a matching image.
+func printUsageTable(usages []k8s.ImageInfo, imageCount int) {
+	if len(usages) == 0 {
+		fmt.Println("No workloads use a matching image")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "IMAGE\tNAMESPACE\tOWNER\tPOD\tCONTAINER\tTYPE")
+	for _, u := range usages {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", u.Image, u.Namespace, formatOwnerChain(u.OwnerChain), u.PodName, u.ContainerName, u.ContainerType)
+	}
+	w.Flush()
+	fmt.Printf("\nTotal: %d containers using %d matching images\n", len(usages), imageCount)
+}
+
+// formatOwnerChain formats an owner chain as "Deployment/web > ReplicaSet/web-7d9f".
+func formatOwnerChain(chain []k8s.OwnerReference) string {
+	parts := make([]string, 0, len(chain))
+	for _, o := range chain {
+		parts = append(parts, o.String())
+	}
+	return strings.Join(parts, " > ")
+}
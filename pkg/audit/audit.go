@@ -0,0 +1,180 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"krane/pkg/k8s"
+	"krane/pkg/match"
+	"krane/pkg/utils"
+)
+
+// Rule levels, matching SARIF result levels.
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+)
+
+// Rule identifiers.
+const (
+	RuleLatestTag          = "latest-tag"
+	RuleUnpinnedDigest     = "unpinned-digest"
+	RuleRegistryNotAllowed = "registry-not-allowed"
+	RuleDeprecatedRegistry = "deprecated-registry"
+	RuleInconsistentTags   = "inconsistent-tags"
+	RuleMissingInECR       = "missing-in-ecr"
+)
+
+// deprecatedRegistry is the frozen legacy Kubernetes image registry.
+const deprecatedRegistry = "k8s.gcr.io"
+
+// Rule describes a single image policy rule.
+type Rule struct {
+	ID          string `json:"id" yaml:"id"`
+	Level       string `json:"level" yaml:"level"`
+	Description string `json:"description" yaml:"description"`
+}
+
+// Rules lists all rules known to the auditor.
+var Rules = []Rule{
+	{ID: RuleLatestTag, Level: LevelError, Description: "Image uses the latest tag or no tag at all"},
+	{ID: RuleUnpinnedDigest, Level: LevelWarning, Description: "Image is not pinned by digest"},
+	{ID: RuleRegistryNotAllowed, Level: LevelError, Description: "Image comes from a registry outside the allowlist"},
+	{ID: RuleDeprecatedRegistry, Level: LevelError, Description: "Image comes from the deprecated k8s.gcr.io registry"},
+	{ID: RuleInconsistentTags, Level: LevelWarning, Description: "Repository is used with different tags across namespaces"},
+	{ID: RuleMissingInECR, Level: LevelError, Description: "Image has not been mirrored to ECR yet"},
+}
+
+// Options configures an audit run.
+type Options struct {
+	// AllowedRegistries are include patterns matched against the registry host, exactly
+	// unless a pattern names another kind.
+	// When empty, the registry-not-allowed rule is not evaluated.
+	AllowedRegistries []string
+	// DisabledRules lists rule IDs that are skipped.
+	DisabledRules []string
+	// ExistsInECR reports whether an image is already mirrored.
+	// When nil, the missing-in-ecr rule is not evaluated.
+	ExistsInECR func(image string) (bool, error)
+}
+
+// Finding is a single rule violation for an image.
+type Finding struct {
+	RuleID  string          `json:"ruleId" yaml:"ruleId"`
+	Level   string          `json:"level" yaml:"level"`
+	Image   string          `json:"image" yaml:"image"`
+	Message string          `json:"message" yaml:"message"`
+	Sources []k8s.ImageInfo `json:"sources" yaml:"sources"`
+}
+
+// Evaluate applies all enabled rules to the discovered images and returns the findings,
+// sorted by image and rule.
+func Evaluate(infos []k8s.ImageInfo, opts Options) ([]Finding, error) {
+	disabled := map[string]bool{}
+	for _, id := range opts.DisabledRules {
+		disabled[strings.TrimSpace(id)] = true
+	}
+	levels := map[string]string{}
+	for _, r := range Rules {
+		levels[r.ID] = r.Level
+	}
+
+	sources := map[string][]k8s.ImageInfo{}
+	var images []string
+	for _, info := range infos {
+		if _, ok := sources[info.Image]; !ok {
+			images = append(images, info.Image)
+		}
+		sources[info.Image] = append(sources[info.Image], info)
+	}
+	sort.Strings(images)
+
+	var findings []Finding
+	add := func(rule, image, message string) {
+		if disabled[rule] {
+			return
+		}
+		findings = append(findings, Finding{
+			RuleID:  rule,
+			Level:   levels[rule],
+			Image:   image,
+			Message: message,
+			Sources: sources[image],
+		})
+	}
+
+	// Registry allowlist, matched against registry hosts with the shared pattern language.
+	// Hosts match exactly by default so docker.io does not also allow docker.io.evil.com
+	allowedMatchers, err := match.CompileAllKind(opts.AllowedRegistries, match.KindExact)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed registry patterns: %w", err)
+	}
+
+	for _, image := range images {
+		ref := utils.ParseImageReference(image)
+
+		switch {
+		case ref.Tag == "" && ref.Digest == "":
+			add(RuleLatestTag, image, "image has no tag and resolves to latest")
+		case ref.Tag == "latest":
+			add(RuleLatestTag, image, "image uses the latest tag")
+		}
+		if ref.Digest == "" {
+			add(RuleUnpinnedDigest, image, "image is not pinned by digest")
+		}
+		if len(allowedMatchers) > 0 && !match.Any(allowedMatchers, ref.Registry) {
+			add(RuleRegistryNotAllowed, image, fmt.Sprintf("registry %s is not in the allowlist", ref.Registry))
+		}
+		if ref.Registry == deprecatedRegistry {
+			add(RuleDeprecatedRegistry, image, "k8s.gcr.io is frozen; use registry.k8s.io instead")
+		}
+		if opts.ExistsInECR != nil && !disabled[RuleMissingInECR] {
+			exists, err := opts.ExistsInECR(image)
+			if err != nil {
+				return nil, fmt.Errorf("checking %s in ECR: %w", image, err)
+			}
+			if !exists {
+				add(RuleMissingInECR, image, "image is not present in ECR")
+			}
+		}
+	}
+
+	addInconsistentTagFindings(images, sources, add)
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Image != findings[j].Image {
+			return findings[i].Image < findings[j].Image
+		}
+		return findings[i].RuleID < findings[j].RuleID
+	})
+	return findings, nil
+}
+
+// addInconsistentTagFindings reports repositories used with different tags in different namespaces.
+func addInconsistentTagFindings(images []string, sources map[string][]k8s.ImageInfo, add func(rule, image, message string)) {
+	byRepo := map[string][]string{}
+	for _, image := range images {
+		name := utils.ParseImageReference(image).Name()
+		byRepo[name] = append(byRepo[name], image)
+	}
+
+	for name, variants := range byRepo {
+		if len(variants) < 2 {
+			continue
+		}
+		namespaces := map[string]bool{}
+		for _, image := range variants {
+			for _, s := range sources[image] {
+				namespaces[s.Namespace] = true
+			}
+		}
+		if len(namespaces) < 2 {
+			continue
+		}
+		for _, image := range variants {
+			add(RuleInconsistentTags, image, fmt.Sprintf("%s is used as %d different references across %d namespaces: %s",
+				name, len(variants), len(namespaces), strings.Join(variants, ", ")))
+		}
+	}
+}
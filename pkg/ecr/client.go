@@ -5,44 +5,189 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/ecr"
 	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
 )
 
+// API is the subset of the ECR service client used by Client.
+// It is satisfied by *ecr.Client and can be replaced by a stub in tests.
+type API interface {
+	GetAuthorizationToken(ctx context.Context, params *ecr.GetAuthorizationTokenInput, optFns ...func(*ecr.Options)) (*ecr.GetAuthorizationTokenOutput, error)
+	CreateRepository(ctx context.Context, params *ecr.CreateRepositoryInput, optFns ...func(*ecr.Options)) (*ecr.CreateRepositoryOutput, error)
+	DescribeRepositories(ctx context.Context, params *ecr.DescribeRepositoriesInput, optFns ...func(*ecr.Options)) (*ecr.DescribeRepositoriesOutput, error)
+	DeleteRepository(ctx context.Context, params *ecr.DeleteRepositoryInput, optFns ...func(*ecr.Options)) (*ecr.DeleteRepositoryOutput, error)
+	DescribeImages(ctx context.Context, params *ecr.DescribeImagesInput, optFns ...func(*ecr.Options)) (*ecr.DescribeImagesOutput, error)
+	BatchDeleteImage(ctx context.Context, params *ecr.BatchDeleteImageInput, optFns ...func(*ecr.Options)) (*ecr.BatchDeleteImageOutput, error)
+	BatchGetImage(ctx context.Context, params *ecr.BatchGetImageInput, optFns ...func(*ecr.Options)) (*ecr.BatchGetImageOutput, error)
+	DescribeImageScanFindings(ctx context.Context, params *ecr.DescribeImageScanFindingsInput, optFns ...func(*ecr.Options)) (*ecr.DescribeImageScanFindingsOutput, error)
+	CreatePullThroughCacheRule(ctx context.Context, params *ecr.CreatePullThroughCacheRuleInput, optFns ...func(*ecr.Options)) (*ecr.CreatePullThroughCacheRuleOutput, error)
+}
+
+// DefaultRegion is used when neither --region nor the AWS environment or profile set a region.
+const DefaultRegion = "eu-west-1"
+
 type Client struct {
-	ecrClient *ecr.Client
-	stsClient *sts.Client
-	region    string
-	accountID string
+	ecrClient   API
+	region      string
+	accountID   string
+	registryURL string
+	logger      *slog.Logger
+}
+
+// Options configures how a Client connects to ECR.
+type Options struct {
+	// Region is the AWS region of the registry; empty uses AWS_REGION or the profile's region.
+	Region string
+	// Profile selects a named profile from the shared AWS config files.
+	Profile string
+	// RoleARN, when set, is assumed via STS before any other call.
+	RoleARN string
+	// ExternalID is passed when assuming RoleARN.
+	ExternalID string
+	// SessionName is the role session name used for assumed roles (default: krane).
+	SessionName string
+	// TargetRoleARN, when set, is assumed via STS so the client operates in that role's account.
+	// It is assumed with the credentials of RoleARN when both are set.
+	TargetRoleARN string
+	// Endpoint overrides the ECR and STS API endpoints, e.g. for a local ECR stand-in.
+	Endpoint string
+	// AccountID, when set, is used instead of asking STS for the caller's account.
+	// It is ignored with TargetRoleARN, whose account is always looked up.
+	AccountID string
+	// UseFIPS selects FIPS endpoints for ECR and STS.
+	UseFIPS bool
+	// UseDualStack selects dual-stack (IPv4 and IPv6) endpoints for ECR and STS.
+	UseDualStack bool
+	// Logger receives the client's log records (default: slog.Default()).
+	Logger *slog.Logger
+	// APIOptions are added to the middleware stack of every ECR and STS call, e.g. for metrics.
+	APIOptions []func(*middleware.Stack) error
 }
 
-func NewClient(region string) (*Client, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(region))
+func NewClient(ctx context.Context, opts Options) (*Client, error) {
+	loadOpts := []func(*config.LoadOptions) error{}
+	if opts.Region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(opts.Region))
+	}
+	if opts.Profile != "" {
+		loadOpts = append(loadOpts, config.WithSharedConfigProfile(opts.Profile))
+	}
+	if opts.UseFIPS {
+		loadOpts = append(loadOpts, config.WithUseFIPSEndpoint(aws.FIPSEndpointStateEnabled))
+	}
+	if opts.UseDualStack {
+		loadOpts = append(loadOpts, config.WithUseDualStackEndpoint(aws.DualStackEndpointStateEnabled))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
+	if cfg.Region == "" {
+		cfg.Region = DefaultRegion
+	}
+	cfg.APIOptions = append(cfg.APIOptions, opts.APIOptions...)
+
+	sessionName := opts.SessionName
+	if sessionName == "" {
+		sessionName = "krane"
+	}
+	stsEndpoint := func(o *sts.Options) {
+		if opts.Endpoint != "" {
+			o.BaseEndpoint = aws.String(opts.Endpoint)
+		}
+	}
+	if opts.RoleARN != "" {
+		assumeRole(&cfg, opts.RoleARN, sessionName, opts.ExternalID, stsEndpoint)
+	}
+	if opts.TargetRoleARN != "" {
+		assumeRole(&cfg, opts.TargetRoleARN, sessionName, "", stsEndpoint)
+	}
 
-	ecrClient := ecr.NewFromConfig(cfg)
-	stsClient := sts.NewFromConfig(cfg)
+	ecrClient := ecr.NewFromConfig(cfg, func(o *ecr.Options) {
+		if opts.Endpoint != "" {
+			o.BaseEndpoint = aws.String(opts.Endpoint)
+		}
+	})
+
+	accountID := opts.AccountID
+	if accountID == "" || opts.TargetRoleARN != "" {
+		// Automatically get Account ID
+		identity, err := sts.NewFromConfig(cfg, stsEndpoint).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err != nil {
+			if opts.RoleARN != "" || opts.TargetRoleARN != "" {
+				return nil, fmt.Errorf("failed to assume role: %w", err)
+			}
+			return nil, fmt.Errorf("failed to get AWS account ID: %w", err)
+		}
+		accountID = aws.ToString(identity.Account)
+	}
 
-	// Automatically get Account ID
-	identity, err := stsClient.GetCallerIdentity(context.TODO(), &sts.GetCallerIdentityInput{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get AWS account ID: %w", err)
+	client := NewClientFromAPI(ecrClient, cfg.Region, accountID)
+	if opts.Logger != nil {
+		client.logger = opts.Logger
 	}
+	switch {
+	case opts.Endpoint != "":
+		// A custom endpoint serves its own registry; ask it where that is
+		registry, err := client.proxyEndpoint(ctx)
+		if err != nil {
+			return nil, err
+		}
+		client.registryURL = registry
+	case opts.UseFIPS:
+		client.registryURL = fmt.Sprintf("%s.dkr.ecr-fips.%s.amazonaws.com", client.accountID, client.region)
+	case opts.UseDualStack:
+		client.registryURL = fmt.Sprintf("%s.dkr-ecr.%s.on.aws", client.accountID, client.region)
+	}
+	return client, nil
+}
 
+// assumeRole replaces the credentials in cfg with those of roleARN, assumed with the current ones.
+func assumeRole(cfg *aws.Config, roleARN, sessionName, externalID string, stsOptions ...func(*sts.Options)) {
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(*cfg, stsOptions...), roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = sessionName
+		if externalID != "" {
+			o.ExternalID = aws.String(externalID)
+		}
+	})
+	cfg.Credentials = aws.NewCredentialsCache(provider)
+}
+
+// NewClientFromAPI creates a client backed by the given ECR API implementation
+// for a known account and region, without contacting AWS.
+func NewClientFromAPI(api API, region, accountID string) *Client {
 	return &Client{
-		ecrClient: ecrClient,
-		stsClient: stsClient,
-		region:    region,
-		accountID: *identity.Account,
-	}, nil
+		ecrClient:   api,
+		region:      region,
+		accountID:   accountID,
+		registryURL: fmt.Sprintf("%s.dkr.ecr.%s.amazonaws.com", accountID, region),
+		logger:      slog.Default(),
+	}
+}
+
+// proxyEndpoint returns the registry host reported by GetAuthorizationToken.
+func (c *Client) proxyEndpoint(ctx context.Context) (string, error) {
+	result, err := c.ecrClient.GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get ECR registry endpoint: %w", err)
+	}
+	if len(result.AuthorizationData) == 0 || result.AuthorizationData[0].ProxyEndpoint == nil {
+		return "", fmt.Errorf("no registry endpoint returned")
+	}
+	endpoint := *result.AuthorizationData[0].ProxyEndpoint
+	endpoint = strings.TrimPrefix(endpoint, "https://")
+	endpoint = strings.TrimPrefix(endpoint, "http://")
+	return strings.TrimSuffix(endpoint, "/"), nil
 }
 
 // validateECRRepositoryName validates ECR repository name according to AWS naming rules.
@@ -59,9 +204,19 @@ func validateECRRepositoryName(name string) error {
 	return nil
 }
 
+// Region returns the AWS region of the registry.
+func (c *Client) Region() string {
+	return c.region
+}
+
+// AccountID returns the AWS account that owns the registry.
+func (c *Client) AccountID() string {
+	return c.accountID
+}
+
 // GetRegistryURL returns the ECR registry URL for this account and region.
 func (c *Client) GetRegistryURL() string {
-	return fmt.Sprintf("%s.dkr.ecr.%s.amazonaws.com", c.accountID, c.region)
+	return c.registryURL
 }
 
 // GetAuthToken retrieves ECR authentication credentials for Docker operations.
@@ -100,13 +255,13 @@ func (c *Client) CreateRepository(ctx context.Context, repositoryName string) er
 	if err != nil {
 		var already *ecrtypes.RepositoryAlreadyExistsException
 		if errors.As(err, &already) {
-			fmt.Printf("📦 Repository %s already exists\n", repositoryName)
+			c.logger.Debug("repository already exists", "repository", repositoryName, "registry", c.registryURL)
 			return nil
 		}
 		return fmt.Errorf("failed to create repository %s: %w", repositoryName, err)
 	}
 
-	fmt.Printf("✅ Created ECR repository: %s\n", repositoryName)
+	c.logger.Info("created repository", "repository", repositoryName, "registry", c.registryURL)
 	return nil
 }
 
@@ -126,29 +281,9 @@ func (c *Client) ConvertImageName(originalImage, prefix string) (string, string,
 		image = image[:at]
 	}
 
-	// Split into parts
-	parts := strings.Split(image, "/")
-
-	// First part might be registry: contains dots, colons, or 'localhost'
-	startIdx := 0
-	if len(parts) > 1 {
-		first := parts[0]
-		if strings.Contains(first, ".") || strings.Contains(first, ":") || first == "localhost" {
-			startIdx = 1
-		}
-	}
-
-	// If only 2 parts and first is registry, use the second one
-	if len(parts) == 2 && startIdx == 1 {
-		startIdx = 1
-	}
-
 	// Repository path (excluding registry)
-	repoParts := parts[startIdx:]
-	if len(repoParts) == 0 {
-		// Single name only (e.g., "busybox")
-		repoParts = []string{image}
-	}
+	_, path := splitRegistry(image)
+	repoParts := strings.Split(path, "/")
 
 	// Extract tag from last part
 	last := repoParts[len(repoParts)-1]
@@ -195,6 +330,28 @@ func (c *Client) ConvertImageName(originalImage, prefix string) (string, string,
 	return ecrImage, fullRepoName, nil
 }
 
+// ecrHostPattern matches the registry hosts of private ECR registries in any account and region.
+var ecrHostPattern = regexp.MustCompile(`^[0-9]{12}\.(dkr\.ecr(-fips)?\.[a-z0-9-]+\.amazonaws\.com(\.cn)?|dkr-ecr\.[a-z0-9-]+\.on\.aws)$`)
+
+// ECRRepository returns the repository and tag of an image that is already stored in this
+// registry or any other ECR registry, or false for images from other registries. The tag
+// is empty for references by digest only.
+func (c *Client) ECRRepository(image string) (string, string, bool) {
+	defaultTag := "latest"
+	if at := strings.Index(image, "@"); at != -1 {
+		image = image[:at]
+		defaultTag = ""
+	}
+	host, path := splitRegistry(image)
+	if host != c.registryURL && !ecrHostPattern.MatchString(host) {
+		return "", "", false
+	}
+	if idx := strings.LastIndex(path, ":"); idx != -1 && !strings.Contains(path[idx:], "/") {
+		return path[:idx], path[idx+1:], true
+	}
+	return path, defaultTag, true
+}
+
 // ImageTagExists checks whether a specific tag exists in the given ECR repository.
 func (c *Client) ImageTagExists(ctx context.Context, repositoryName, tag string) (bool, error) {
 	input := &ecr.DescribeImagesInput{
@@ -216,3 +373,16 @@ func (c *Client) ImageTagExists(ctx context.Context, repositoryName, tag string)
 	}
 	return len(out.ImageDetails) > 0, nil
 }
+
+// splitRegistry splits an image reference into its registry host and the remaining path.
+// The first part is a registry if it contains dots, colons, or is 'localhost'; otherwise
+// the image is on Docker Hub.
+func splitRegistry(image string) (string, string) {
+	if parts := strings.SplitN(image, "/", 2); len(parts) == 2 {
+		first := parts[0]
+		if strings.Contains(first, ".") || strings.Contains(first, ":") || first == "localhost" {
+			return first, parts[1]
+		}
+	}
+	return "docker.io", image
+}
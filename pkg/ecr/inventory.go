@@ -0,0 +1,201 @@
+package ecr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+)
+
+// batchDeleteLimit is the maximum number of image IDs accepted by a single BatchDeleteImage call.
+const batchDeleteLimit = 100
+
+// batchGetLimit is the maximum number of image IDs accepted by a single BatchGetImage call.
+const batchGetLimit = 100
+
+// indexMediaTypes are the manifest media types that list other manifests.
+var indexMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+}
+
+// Repository describes an ECR repository.
+type Repository struct {
+	Name      string    `json:"name" yaml:"name"`
+	URI       string    `json:"uri" yaml:"uri"`
+	CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+}
+
+// Image describes a single image (manifest) stored in an ECR repository.
+type Image struct {
+	Repository string    `json:"repository" yaml:"repository"`
+	Digest     string    `json:"digest" yaml:"digest"`
+	Tags       []string  `json:"tags" yaml:"tags"`
+	SizeBytes  int64     `json:"sizeBytes" yaml:"sizeBytes"`
+	PushedAt   time.Time `json:"pushedAt" yaml:"pushedAt"`
+	MediaType  string    `json:"mediaType,omitempty" yaml:"mediaType,omitempty"`
+}
+
+// IsIndex reports whether the image is a multi-platform index (manifest list).
+func (i Image) IsIndex() bool {
+	for _, mt := range indexMediaTypes {
+		if i.MediaType == mt {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteFailure describes an image that could not be deleted.
+type DeleteFailure struct {
+	Digest string `json:"digest" yaml:"digest"`
+	Code   string `json:"code" yaml:"code"`
+	Reason string `json:"reason" yaml:"reason"`
+}
+
+// ListRepositories lists all repositories whose name is below the given prefix.
+func (c *Client) ListRepositories(ctx context.Context, prefix string) ([]Repository, error) {
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	var repos []Repository
+	paginator := ecr.NewDescribeRepositoriesPaginator(c.ecrClient, &ecr.DescribeRepositoriesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe repositories: %w", err)
+		}
+		for _, r := range page.Repositories {
+			name := aws.ToString(r.RepositoryName)
+			if prefix != "" && !strings.HasPrefix(name, prefix+"/") {
+				continue
+			}
+			repos = append(repos, Repository{
+				Name:      name,
+				URI:       aws.ToString(r.RepositoryUri),
+				CreatedAt: aws.ToTime(r.CreatedAt),
+			})
+		}
+	}
+	return repos, nil
+}
+
+// ListImages lists all images stored in the given repository, tagged or not.
+func (c *Client) ListImages(ctx context.Context, repositoryName string) ([]Image, error) {
+	var images []Image
+	paginator := ecr.NewDescribeImagesPaginator(c.ecrClient, &ecr.DescribeImagesInput{
+		RepositoryName: aws.String(repositoryName),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			var rnfe *ecrtypes.RepositoryNotFoundException
+			if errors.As(err, &rnfe) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to describe images in %s: %w", repositoryName, err)
+		}
+		for _, d := range page.ImageDetails {
+			images = append(images, Image{
+				Repository: repositoryName,
+				Digest:     aws.ToString(d.ImageDigest),
+				Tags:       d.ImageTags,
+				SizeBytes:  aws.ToInt64(d.ImageSizeInBytes),
+				PushedAt:   aws.ToTime(d.ImagePushedAt),
+				MediaType:  aws.ToString(d.ImageManifestMediaType),
+			})
+		}
+	}
+	return images, nil
+}
+
+// IndexChildren returns the digests of the manifests listed by each of the given index digests.
+func (c *Client) IndexChildren(ctx context.Context, repositoryName string, digests []string) (map[string][]string, error) {
+	children := make(map[string][]string, len(digests))
+	for start := 0; start < len(digests); start += batchGetLimit {
+		end := start + batchGetLimit
+		if end > len(digests) {
+			end = len(digests)
+		}
+
+		ids := make([]ecrtypes.ImageIdentifier, 0, end-start)
+		for _, d := range digests[start:end] {
+			ids = append(ids, ecrtypes.ImageIdentifier{ImageDigest: aws.String(d)})
+		}
+		out, err := c.ecrClient.BatchGetImage(ctx, &ecr.BatchGetImageInput{
+			RepositoryName:     aws.String(repositoryName),
+			ImageIds:           ids,
+			AcceptedMediaTypes: indexMediaTypes,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get manifests from %s: %w", repositoryName, err)
+		}
+		for _, img := range out.Images {
+			var index struct {
+				Manifests []struct {
+					Digest string `json:"digest"`
+				} `json:"manifests"`
+			}
+			if err := json.Unmarshal([]byte(aws.ToString(img.ImageManifest)), &index); err != nil {
+				return nil, fmt.Errorf("failed to parse manifest %s in %s: %w", aws.ToString(img.ImageId.ImageDigest), repositoryName, err)
+			}
+			digest := aws.ToString(img.ImageId.ImageDigest)
+			for _, m := range index.Manifests {
+				children[digest] = append(children[digest], m.Digest)
+			}
+		}
+	}
+	return children, nil
+}
+
+// DeleteImages deletes the images with the given digests from a repository. Images that
+// ECR refuses to delete, e.g. because an index still references them, are returned as
+// failures; an error means a request failed and later batches were not attempted.
+func (c *Client) DeleteImages(ctx context.Context, repositoryName string, digests []string) ([]DeleteFailure, error) {
+	var failures []DeleteFailure
+	for start := 0; start < len(digests); start += batchDeleteLimit {
+		end := start + batchDeleteLimit
+		if end > len(digests) {
+			end = len(digests)
+		}
+
+		ids := make([]ecrtypes.ImageIdentifier, 0, end-start)
+		for _, d := range digests[start:end] {
+			ids = append(ids, ecrtypes.ImageIdentifier{ImageDigest: aws.String(d)})
+		}
+		c.logger.Debug("deleting images", "repository", repositoryName, "count", len(ids))
+
+		out, err := c.ecrClient.BatchDeleteImage(ctx, &ecr.BatchDeleteImageInput{
+			RepositoryName: aws.String(repositoryName),
+			ImageIds:       ids,
+		})
+		if err != nil {
+			return failures, fmt.Errorf("failed to delete images from %s: %w", repositoryName, err)
+		}
+		for _, f := range out.Failures {
+			var digest string
+			if f.ImageId != nil {
+				digest = aws.ToString(f.ImageId.ImageDigest)
+			}
+			failures = append(failures, DeleteFailure{Digest: digest, Code: string(f.FailureCode), Reason: aws.ToString(f.FailureReason)})
+		}
+	}
+	return failures, nil
+}
+
+// DeleteRepository deletes a repository together with all images it contains.
+func (c *Client) DeleteRepository(ctx context.Context, repositoryName string) error {
+	_, err := c.ecrClient.DeleteRepository(ctx, &ecr.DeleteRepositoryInput{
+		RepositoryName: aws.String(repositoryName),
+		Force:          true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete repository %s: %w", repositoryName, err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package ecr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+)
+
+// PullThroughUpstream is an upstream registry that ECR can serve through a pull-through cache rule.
+type PullThroughUpstream struct {
+	// Name is the short name used in the rule's repository prefix and in --ptc-credential.
+	Name string
+	// Registry is the registry host as it appears in image references.
+	Registry string
+	// URL is the upstream registry URL passed to ECR.
+	URL string
+	// CredentialRequired is set for upstreams that need a Secrets Manager secret.
+	CredentialRequired bool
+}
+
+// PullThroughUpstreams lists the upstream registries supported by ECR pull-through cache.
+var PullThroughUpstreams = []PullThroughUpstream{
+	{Name: "ecr-public", Registry: "public.ecr.aws", URL: "public.ecr.aws"},
+	{Name: "k8s", Registry: "registry.k8s.io", URL: "registry.k8s.io"},
+	{Name: "quay", Registry: "quay.io", URL: "quay.io"},
+	{Name: "docker-hub", Registry: "docker.io", URL: "registry-1.docker.io", CredentialRequired: true},
+	{Name: "ghcr", Registry: "ghcr.io", URL: "ghcr.io", CredentialRequired: true},
+	{Name: "gitlab", Registry: "registry.gitlab.com", URL: "registry.gitlab.com", CredentialRequired: true},
+}
+
+// PullThroughUpstreamFor returns the pull-through upstream for a registry host.
+func PullThroughUpstreamFor(registry string) (PullThroughUpstream, bool) {
+	if registry == "index.docker.io" || registry == "registry-1.docker.io" {
+		registry = "docker.io"
+	}
+	for _, u := range PullThroughUpstreams {
+		if u.Registry == registry {
+			return u, true
+		}
+	}
+	return PullThroughUpstream{}, false
+}
+
+// RepositoryPrefix returns the ECR repository prefix of the rule for this upstream.
+func (u PullThroughUpstream) RepositoryPrefix(prefix string) string {
+	return prefix + "/" + u.Name
+}
+
+// PullThroughImageName returns the reference under which originalImage is served by the
+// pull-through cache rule for its registry. The tag or digest is kept unchanged.
+func (c *Client) PullThroughImageName(originalImage, prefix string) (string, PullThroughUpstream, bool) {
+	registry, path := splitRegistry(originalImage)
+	upstream, ok := PullThroughUpstreamFor(registry)
+	if !ok {
+		return "", PullThroughUpstream{}, false
+	}
+	// Docker Hub official images live under library/
+	if upstream.Name == "docker-hub" && !strings.Contains(path, "/") {
+		path = "library/" + path
+	}
+	return fmt.Sprintf("%s/%s/%s", c.GetRegistryURL(), upstream.RepositoryPrefix(prefix), path), upstream, true
+}
+
+// CreatePullThroughCacheRule creates the pull-through cache rule for an upstream under prefix.
+// It returns false without error if the rule already exists.
+func (c *Client) CreatePullThroughCacheRule(ctx context.Context, prefix string, upstream PullThroughUpstream, credentialARN string) (bool, error) {
+	input := &ecr.CreatePullThroughCacheRuleInput{
+		EcrRepositoryPrefix: aws.String(upstream.RepositoryPrefix(prefix)),
+		UpstreamRegistryUrl: aws.String(upstream.URL),
+	}
+	if credentialARN != "" {
+		input.CredentialArn = aws.String(credentialARN)
+	}
+
+	_, err := c.ecrClient.CreatePullThroughCacheRule(ctx, input)
+	if err != nil {
+		var already *ecrtypes.PullThroughCacheRuleAlreadyExistsException
+		if errors.As(err, &already) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to create pull-through cache rule %s: %w", upstream.RepositoryPrefix(prefix), err)
+	}
+	return true, nil
+}
@@ -0,0 +1,188 @@
+package ecr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ecr"
+	ecrtypes "github.com/aws/aws-sdk-go-v2/service/ecr/types"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Severities lists ECR finding severities from most to least severe.
+var Severities = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "INFORMATIONAL", "UNDEFINED"}
+
+// Scan statuses reported in addition to the ECR scan states.
+const (
+	ScanStatusNotFound    = "NOT_FOUND"
+	ScanStatusNotMirrored = "NOT_MIRRORED"
+	// ScanStatusNoPlatform is reported for multi-platform images without a matching platform image.
+	ScanStatusNoPlatform = "NO_PLATFORM"
+)
+
+// SeverityRank returns the rank of a severity (higher is more severe), or -1 if unknown.
+func SeverityRank(severity string) int {
+	severity = strings.ToUpper(severity)
+	for i, s := range Severities {
+		if s == severity {
+			return len(Severities) - i
+		}
+	}
+	return -1
+}
+
+// ScanFindings summarizes the scan result of a single image.
+type ScanFindings struct {
+	Repository string         `json:"repository" yaml:"repository"`
+	Tag        string         `json:"tag" yaml:"tag"`
+	Status     string         `json:"status" yaml:"status"`
+	Counts     map[string]int `json:"counts" yaml:"counts"`
+	// Platforms lists the platform images whose findings were added up for a multi-platform image.
+	Platforms []string `json:"platforms,omitempty" yaml:"platforms,omitempty"`
+}
+
+// GetScanFindings returns the finding counts per severity for an image tag. ECR scans the
+// platform images of a multi-platform image, not its index, so their findings are added up;
+// platforms restricts them, and an empty list means all. Missing repositories, images and
+// scans are reported through Status instead of an error.
+func (c *Client) GetScanFindings(ctx context.Context, repositoryName, tag string, platforms []v1.Platform) (*ScanFindings, error) {
+	result := &ScanFindings{Repository: repositoryName, Tag: tag, Counts: map[string]int{}}
+
+	described, err := c.ecrClient.DescribeImages(ctx, &ecr.DescribeImagesInput{
+		RepositoryName: aws.String(repositoryName),
+		ImageIds:       []ecrtypes.ImageIdentifier{{ImageTag: aws.String(tag)}},
+	})
+	if err != nil {
+		var rnfe *ecrtypes.RepositoryNotFoundException
+		var infe *ecrtypes.ImageNotFoundException
+		if errors.As(err, &rnfe) || errors.As(err, &infe) {
+			result.Status = ScanStatusNotMirrored
+			return result, nil
+		}
+		return nil, fmt.Errorf("failed to describe image %s:%s: %w", repositoryName, tag, err)
+	}
+	if len(described.ImageDetails) == 0 {
+		result.Status = ScanStatusNotMirrored
+		return result, nil
+	}
+	detail := described.ImageDetails[0]
+	image := Image{Digest: aws.ToString(detail.ImageDigest), MediaType: aws.ToString(detail.ImageManifestMediaType)}
+	if !image.IsIndex() {
+		status, err := c.imageScanFindings(ctx, repositoryName, ecrtypes.ImageIdentifier{ImageTag: aws.String(tag)}, result.Counts)
+		if err != nil {
+			return nil, err
+		}
+		result.Status = status
+		return result, nil
+	}
+
+	children, err := c.indexPlatforms(ctx, repositoryName, image.Digest)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range children {
+		if len(platforms) > 0 && !satisfiesAny(child.platform, platforms) {
+			continue
+		}
+		status, err := c.imageScanFindings(ctx, repositoryName, ecrtypes.ImageIdentifier{ImageDigest: aws.String(child.digest)}, result.Counts)
+		if err != nil {
+			return nil, err
+		}
+		result.Platforms = append(result.Platforms, child.platform.String())
+		// An incomplete platform scan makes the whole image incomplete
+		if result.Status == "" || isCompleteScan(result.Status) && !isCompleteScan(status) {
+			result.Status = status
+		}
+	}
+	if len(result.Platforms) == 0 {
+		result.Status = ScanStatusNoPlatform
+	}
+	return result, nil
+}
+
+// imageScanFindings adds the finding counts of a single image manifest to counts and returns its scan status.
+func (c *Client) imageScanFindings(ctx context.Context, repositoryName string, id ecrtypes.ImageIdentifier, counts map[string]int) (string, error) {
+	out, err := c.ecrClient.DescribeImageScanFindings(ctx, &ecr.DescribeImageScanFindingsInput{
+		RepositoryName: aws.String(repositoryName),
+		ImageId:        &id,
+		MaxResults:     aws.Int32(1),
+	})
+	if err != nil {
+		var rnfe *ecrtypes.RepositoryNotFoundException
+		var infe *ecrtypes.ImageNotFoundException
+		if errors.As(err, &rnfe) || errors.As(err, &infe) {
+			return ScanStatusNotMirrored, nil
+		}
+		var snfe *ecrtypes.ScanNotFoundException
+		if errors.As(err, &snfe) {
+			return ScanStatusNotFound, nil
+		}
+		ref := aws.ToString(id.ImageTag)
+		if ref == "" {
+			ref = aws.ToString(id.ImageDigest)
+		}
+		return "", fmt.Errorf("failed to describe scan findings for %s:%s: %w", repositoryName, ref, err)
+	}
+
+	if out.ImageScanFindings != nil {
+		for severity, n := range out.ImageScanFindings.FindingSeverityCounts {
+			counts[strings.ToUpper(severity)] += int(n)
+		}
+	}
+	if out.ImageScanStatus == nil {
+		return "", nil
+	}
+	return string(out.ImageScanStatus.Status), nil
+}
+
+// platformImage is a platform manifest listed by an index.
+type platformImage struct {
+	digest   string
+	platform v1.Platform
+}
+
+// indexPlatforms returns the platform images listed by an index, skipping attestations.
+func (c *Client) indexPlatforms(ctx context.Context, repositoryName, digest string) ([]platformImage, error) {
+	out, err := c.ecrClient.BatchGetImage(ctx, &ecr.BatchGetImageInput{
+		RepositoryName:     aws.String(repositoryName),
+		ImageIds:           []ecrtypes.ImageIdentifier{{ImageDigest: aws.String(digest)}},
+		AcceptedMediaTypes: indexMediaTypes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest %s from %s: %w", digest, repositoryName, err)
+	}
+	if len(out.Images) == 0 {
+		return nil, fmt.Errorf("manifest %s not found in %s", digest, repositoryName)
+	}
+	index, err := v1.ParseIndexManifest(strings.NewReader(aws.ToString(out.Images[0].ImageManifest)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s in %s: %w", digest, repositoryName, err)
+	}
+
+	var images []platformImage
+	for _, m := range index.Manifests {
+		if m.Platform == nil || m.Platform.OS == "unknown" {
+			continue
+		}
+		images = append(images, platformImage{digest: m.Digest.String(), platform: *m.Platform})
+	}
+	return images, nil
+}
+
+// satisfiesAny reports whether p satisfies any of the requested platforms.
+func satisfiesAny(p v1.Platform, platforms []v1.Platform) bool {
+	for _, spec := range platforms {
+		if p.Satisfies(spec) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCompleteScan reports whether a scan status carries final findings.
+func isCompleteScan(status string) bool {
+	return status == string(ecrtypes.ScanStatusComplete) || status == string(ecrtypes.ScanStatusActive)
+}
@@ -3,17 +3,41 @@ package k8s
 import (
 	"context"
 	"fmt"
-	"os"
+	"log/slog"
+	"net/http"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 
+	"krane/pkg/match"
+	"krane/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 )
 
+// WrapTransport, when set, wraps the HTTP transport of clientsets created by this package,
+// e.g. to record API metrics.
+var WrapTransport func(http.RoundTripper) http.RoundTripper
+
+// newClientset creates a clientset for config, applying WrapTransport.
+func newClientset(config *rest.Config) (*kubernetes.Clientset, error) {
+	if WrapTransport != nil {
+		config.Wrap(WrapTransport)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+	return clientset, nil
+}
+
 // NewClient creates a new Kubernetes clientset from kubeconfig.
 func NewClient(kubeconfig string) (*kubernetes.Clientset, error) {
 	if kubeconfig == "" {
@@ -27,17 +51,33 @@ func NewClient(kubeconfig string) (*kubernetes.Clientset, error) {
 		return nil, fmt.Errorf("failed to build config: %w", err)
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
+	return newClientset(config)
+}
+
+// NewClientForContext creates a Kubernetes clientset for a named kubeconfig context.
+// An empty context name selects the current context.
+func NewClientForContext(kubeconfig, contextName string) (*kubernetes.Clientset, error) {
+	if contextName == "" {
+		return NewClient(kubeconfig)
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: contextName}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create clientset: %w", err)
+		return nil, fmt.Errorf("failed to build config for context %s: %w", contextName, err)
 	}
 
-	return clientset, nil
+	return newClientset(config)
 }
 
 // ListPodImages lists all container images from pods in the specified namespace.
-func ListPodImages(clientset *kubernetes.Clientset, namespace string) ([]string, error) {
-	pods, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+func ListPodImages(ctx context.Context, clientset *kubernetes.Clientset, namespace string) ([]string, error) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list pods: %w", err)
 	}
@@ -58,108 +98,487 @@ func ListPodImages(clientset *kubernetes.Clientset, namespace string) ([]string,
 	return images, nil
 }
 
-// ListPodImagesFiltered lists images from pods with namespace filtering support.
-func ListPodImagesFiltered(clientset *kubernetes.Clientset, allNamespaces bool, baseNamespace string, includeNamespaces, excludeNamespaces []string) ([]string, error) {
-	listNamespace := baseNamespace
-	if allNamespaces {
+// Container types reported in ImageInfo.
+const (
+	ContainerTypeMain      = "main"
+	ContainerTypeInit      = "init"
+	ContainerTypeSidecar   = "sidecar"
+	ContainerTypeEphemeral = "ephemeral"
+)
+
+// SourceKindNode is the source kind of images found in a node's image cache.
+const SourceKindNode = "Node"
+
+// SkipMirrorAnnotation opts a pod out of discovery when set to "true" on the pod or on any
+// controller owning it (e.g. a Deployment and its ReplicaSet).
+const SkipMirrorAnnotation = "krane.io/skip-mirror"
+
+// DiscoveryOptions controls which pods and containers are inspected during image discovery.
+type DiscoveryOptions struct {
+	AllNamespaces     bool
+	Namespace         string
+	IncludeNamespaces []string
+	ExcludeNamespaces []string
+	// IncludeEphemeral also reports images of ephemeral (debug) containers.
+	IncludeEphemeral bool
+	// IncludeNodeImages also reports images cached on nodes (node.Status.Images).
+	IncludeNodeImages bool
+	// LabelSelector and FieldSelector restrict the listed pods.
+	LabelSelector string
+	FieldSelector string
+	// NamespaceSelector restricts pods to namespaces whose labels match.
+	NamespaceSelector string
+	// SkipAnnotated drops pods annotated with SkipMirrorAnnotation=true.
+	SkipAnnotated bool
+	// ExcludeTerminal drops pods in the Succeeded or Failed (including Evicted) phase.
+	ExcludeTerminal bool
+	// Logger receives discovery log records (default: slog.Default()).
+	Logger *slog.Logger
+}
+
+// Validate reports options that cannot be combined. Node images belong to no namespace or
+// pod, so namespace and pod filters cannot be applied to them.
+func (opts DiscoveryOptions) Validate() error {
+	if !opts.IncludeNodeImages {
+		return nil
+	}
+	if !opts.AllNamespaces || len(opts.IncludeNamespaces) > 0 || len(opts.ExcludeNamespaces) > 0 || opts.NamespaceSelector != "" {
+		return fmt.Errorf("node images cannot be combined with namespace filters: they belong to no namespace")
+	}
+	if opts.LabelSelector != "" || opts.FieldSelector != "" {
+		return fmt.Errorf("node images cannot be combined with pod selectors: they belong to no pod")
+	}
+	return nil
+}
+
+// log returns the configured logger or the default one.
+func (opts DiscoveryOptions) log() *slog.Logger {
+	if opts.Logger != nil {
+		return opts.Logger
+	}
+	return slog.Default()
+}
+
+// imagePullFailureReasons are container waiting reasons caused by image pull problems.
+var imagePullFailureReasons = map[string]bool{
+	"ImagePullBackOff":    true,
+	"ErrImagePull":        true,
+	"ErrImageNeverPull":   true,
+	"InvalidImageName":    true,
+	"RegistryUnavailable": true,
+}
+
+// IsImagePullFailure reports whether a container waiting reason indicates a failed image pull.
+func IsImagePullFailure(reason string) bool {
+	return imagePullFailureReasons[reason]
+}
+
+// podContainer is a container image together with the container name, type and pull policy.
+type podContainer struct {
+	name       string
+	image      string
+	kind       string
+	pullPolicy corev1.PullPolicy
+}
+
+// podContainers returns the containers of a pod: main containers first, then init
+// containers (native sidecars are init containers with restartPolicy Always).
+func podContainers(pod *corev1.Pod, includeEphemeral bool) []podContainer {
+	var out []podContainer
+	for _, c := range pod.Spec.Containers {
+		out = append(out, podContainer{name: c.Name, image: c.Image, kind: ContainerTypeMain, pullPolicy: c.ImagePullPolicy})
+	}
+	for _, c := range pod.Spec.InitContainers {
+		kind := ContainerTypeInit
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			kind = ContainerTypeSidecar
+		}
+		out = append(out, podContainer{name: c.Name, image: c.Image, kind: kind, pullPolicy: c.ImagePullPolicy})
+	}
+	if includeEphemeral {
+		for _, c := range pod.Spec.EphemeralContainers {
+			out = append(out, podContainer{name: c.Name, image: c.Image, kind: ContainerTypeEphemeral, pullPolicy: c.ImagePullPolicy})
+		}
+	}
+	return out
+}
+
+// listPods lists pods in the selected namespace(s) and applies namespace filters. It also
+// returns the images of pods dropped for SkipMirrorAnnotation.
+func listPods(ctx context.Context, clientset *kubernetes.Clientset, opts DiscoveryOptions) ([]corev1.Pod, map[string]bool, error) {
+	listNamespace := opts.Namespace
+	if opts.AllNamespaces {
 		listNamespace = metav1.NamespaceAll
 	}
 
-	pods, err := clientset.CoreV1().Pods(listNamespace).List(context.TODO(), metav1.ListOptions{})
+	incMatchers, err := compileNamespaceMatchers(opts.IncludeNamespaces)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list pods: %w", err)
+		return nil, nil, err
+	}
+	excMatchers, err := compileNamespaceMatchers(opts.ExcludeNamespaces)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Compile namespace matchers: regex if derlenebilir, aksi halde prefix
-	incMatchers, _ := compileNamespaceMatchers(includeNamespaces)
-	excMatchers, _ := compileNamespaceMatchers(excludeNamespaces)
+	pods, err := clientset.CoreV1().Pods(listNamespace).List(ctx, metav1.ListOptions{
+		LabelSelector: opts.LabelSelector,
+		FieldSelector: opts.FieldSelector,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list pods: %w", err)
+	}
 
-	var images []string
+	var selectedNamespaces map[string]bool
+	if opts.NamespaceSelector != "" {
+		namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: opts.NamespaceSelector})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list namespaces: %w", err)
+		}
+		selectedNamespaces = map[string]bool{}
+		for _, ns := range namespaces.Items {
+			selectedNamespaces[ns.Name] = true
+		}
+	}
+
+	log := opts.log()
+	log.Debug("listed pods", "namespace", listNamespace, "count", len(pods.Items))
+
+	var out []corev1.Pod
+	skipped := map[string]bool{}
+	ownersSkipped := map[string]bool{} // by namespace/kind/name
 	for _, pod := range pods.Items {
 		ns := pod.Namespace
-		if allNamespaces {
-			if len(incMatchers) > 0 && !namespaceMatchesAny(ns, incMatchers) {
+		if opts.AllNamespaces {
+			if len(incMatchers) > 0 && !match.Any(incMatchers, ns) {
 				continue
 			}
-			if len(excMatchers) > 0 && namespaceMatchesAny(ns, excMatchers) {
+			if len(excMatchers) > 0 && match.Any(excMatchers, ns) {
 				continue
 			}
 		}
+		if selectedNamespaces != nil && !selectedNamespaces[ns] {
+			continue
+		}
+		if opts.SkipAnnotated && (pod.Annotations[SkipMirrorAnnotation] == "true" ||
+			len(pod.OwnerReferences) > 0 && ownerSkipped(ctx, clientset, ns, pod.OwnerReferences[0], ownersSkipped)) {
+			log.Debug("skipping annotated pod", "namespace", ns, "pod", pod.Name, "annotation", SkipMirrorAnnotation)
+			for _, c := range podContainers(&pod, true) {
+				skipped[c.image] = true
+			}
+			continue
+		}
+		if opts.ExcludeTerminal && (pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed) {
+			log.Debug("skipping terminal pod", "namespace", ns, "pod", pod.Name, "phase", pod.Status.Phase)
+			continue
+		}
+		out = append(out, pod)
+	}
+	return out, skipped, nil
+}
 
-		for _, container := range pod.Spec.Containers {
-			images = append(images, container.Image)
+// ListPodImagesFiltered lists images from pods with namespace filtering support.
+func ListPodImagesFiltered(ctx context.Context, clientset *kubernetes.Clientset, opts DiscoveryOptions) ([]string, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	pods, skipped, err := listPods(ctx, clientset, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []string
+	for i := range pods {
+		for _, c := range podContainers(&pods[i], opts.IncludeEphemeral) {
+			images = append(images, c.image)
 		}
-		for _, container := range pod.Spec.InitContainers {
-			images = append(images, container.Image)
+	}
+
+	if opts.IncludeNodeImages {
+		nodeImages, err := listUnskippedNodeImages(ctx, clientset, skipped, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range nodeImages {
+			images = append(images, info.Image)
 		}
 	}
 	return images, nil
 }
 
+// OwnerReference identifies one controller in a pod's owner chain.
+type OwnerReference struct {
+	Kind string `json:"kind" yaml:"kind"`
+	Name string `json:"name" yaml:"name"`
+}
+
+// String returns the owner as Kind/name.
+func (o OwnerReference) String() string {
+	return o.Kind + "/" + o.Name
+}
+
 // ImageInfo contains an image and its source owner information.
 type ImageInfo struct {
-	Image      string `json:"image" yaml:"image"`
-	Namespace  string `json:"namespace" yaml:"namespace"`
-	SourceKind string `json:"sourceKind" yaml:"sourceKind"`
-	SourceName string `json:"sourceName" yaml:"sourceName"`
+	Image         string           `json:"image" yaml:"image"`
+	Namespace     string           `json:"namespace" yaml:"namespace"`
+	SourceKind    string           `json:"sourceKind" yaml:"sourceKind"`
+	SourceName    string           `json:"sourceName" yaml:"sourceName"`
+	OwnerChain    []OwnerReference `json:"ownerChain,omitempty" yaml:"ownerChain,omitempty"`
+	PodName       string           `json:"podName" yaml:"podName"`
+	ContainerName string           `json:"containerName" yaml:"containerName"`
+	ContainerType string           `json:"containerType" yaml:"containerType"`
+	NodeName      string           `json:"nodeName,omitempty" yaml:"nodeName,omitempty"`
+	PodPhase      string           `json:"podPhase,omitempty" yaml:"podPhase,omitempty"`
+	PullPolicy    string           `json:"imagePullPolicy,omitempty" yaml:"imagePullPolicy,omitempty"`
+	// WaitingReason and WaitingMessage come from the container status while the container is waiting.
+	WaitingReason  string `json:"waitingReason,omitempty" yaml:"waitingReason,omitempty"`
+	WaitingMessage string `json:"waitingMessage,omitempty" yaml:"waitingMessage,omitempty"`
 }
 
 // ListPodImagesWithSource lists images with their source controller information.
-func ListPodImagesWithSource(clientset *kubernetes.Clientset, allNamespaces bool, baseNamespace string, includeNamespaces, excludeNamespaces []string) ([]ImageInfo, error) {
-	listNamespace := baseNamespace
-	if allNamespaces {
-		listNamespace = metav1.NamespaceAll
+func ListPodImagesWithSource(ctx context.Context, clientset *kubernetes.Clientset, opts DiscoveryOptions) ([]ImageInfo, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
 	}
-
-	pods, err := clientset.CoreV1().Pods(listNamespace).List(context.TODO(), metav1.ListOptions{})
+	pods, skipped, err := listPods(ctx, clientset, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list pods: %w", err)
+		return nil, err
 	}
 
-	incMatchers, _ := compileNamespaceMatchers(includeNamespaces)
-	excMatchers, _ := compileNamespaceMatchers(excludeNamespaces)
+	// Owner lookups are shared by all pods of the same controller
+	chains := map[string][]OwnerReference{}
 
 	var results []ImageInfo
-	for _, pod := range pods.Items {
+	for i := range pods {
+		pod := &pods[i]
 		ns := pod.Namespace
-		if allNamespaces {
-			if len(incMatchers) > 0 && !namespaceMatchesAny(ns, incMatchers) {
-				continue
-			}
-			if len(excMatchers) > 0 && namespaceMatchesAny(ns, excMatchers) {
-				continue
+
+		chain := []OwnerReference{{Kind: "Pod", Name: pod.Name}}
+		if len(pod.OwnerReferences) > 0 {
+			or := pod.OwnerReferences[0]
+			key := ns + "/" + or.Kind + "/" + or.Name
+			ownerChain, ok := chains[key]
+			if !ok {
+				// Try to resolve the owner chain (e.g., Deployment -> ReplicaSet, CronJob -> Job)
+				ownerChain = ResolveOwnerChain(ctx, clientset, ns, or.Kind, or.Name)
+				chains[key] = ownerChain
 			}
+			chain = ownerChain
 		}
+		top := chain[0]
 
-		kind := "Pod"
-		owner := pod.Name
-		if len(pod.OwnerReferences) > 0 {
-			kind = pod.OwnerReferences[0].Kind
-			owner = pod.OwnerReferences[0].Name
-			// Try to resolve top owner (e.g., ReplicaSet -> Deployment, Job -> CronJob)
-			if topKind, topName, err := ResolveTopOwner(clientset, ns, kind, owner); err == nil {
-				if topKind != "" {
-					kind = topKind
-					owner = topName
+		waiting := map[string]*corev1.ContainerStateWaiting{}
+		for _, statuses := range [][]corev1.ContainerStatus{pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses, pod.Status.EphemeralContainerStatuses} {
+			for _, cs := range statuses {
+				if cs.State.Waiting != nil {
+					waiting[cs.Name] = cs.State.Waiting
 				}
 			}
 		}
 
-		for _, c := range pod.Spec.Containers {
-			results = append(results, ImageInfo{Image: c.Image, Namespace: ns, SourceKind: kind, SourceName: owner})
+		for _, c := range podContainers(pod, opts.IncludeEphemeral) {
+			info := ImageInfo{
+				Image:         c.image,
+				Namespace:     ns,
+				SourceKind:    top.Kind,
+				SourceName:    top.Name,
+				OwnerChain:    chain,
+				PodName:       pod.Name,
+				ContainerName: c.name,
+				ContainerType: c.kind,
+				NodeName:      pod.Spec.NodeName,
+				PodPhase:      string(pod.Status.Phase),
+				PullPolicy:    string(c.pullPolicy),
+			}
+			if w := waiting[c.name]; w != nil {
+				info.WaitingReason = w.Reason
+				info.WaitingMessage = w.Message
+			}
+			results = append(results, info)
 		}
-		for _, c := range pod.Spec.InitContainers {
-			results = append(results, ImageInfo{Image: c.Image, Namespace: ns, SourceKind: kind, SourceName: owner})
+	}
+
+	if opts.IncludeNodeImages {
+		nodeImages, err := listUnskippedNodeImages(ctx, clientset, skipped, opts)
+		if err != nil {
+			return nil, err
 		}
+		results = append(results, nodeImages...)
 	}
 	return results, nil
 }
 
+// listUnskippedNodeImages lists node images, dropping those of pods that opted out of mirroring.
+func listUnskippedNodeImages(ctx context.Context, clientset *kubernetes.Clientset, skipped map[string]bool, opts DiscoveryOptions) ([]ImageInfo, error) {
+	nodeImages, err := ListNodeImages(ctx, clientset)
+	if err != nil {
+		return nil, err
+	}
+	var out []ImageInfo
+	for _, info := range nodeImages {
+		if skipped[info.Image] {
+			opts.log().Debug("skipping node image of annotated pod", "node", info.NodeName, "image", info.Image)
+			continue
+		}
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+// ListNodeImages lists the images cached on each node, attributed to the node.
+// Each cached image is reported once, preferring a tagged name over a digest name.
+func ListNodeImages(ctx context.Context, clientset *kubernetes.Clientset) ([]ImageInfo, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	var results []ImageInfo
+	for _, node := range nodes.Items {
+		for _, img := range node.Status.Images {
+			name := nodeImageName(img.Names)
+			if name == "" {
+				continue
+			}
+			results = append(results, ImageInfo{
+				Image:      name,
+				SourceKind: SourceKindNode,
+				SourceName: node.Name,
+				OwnerChain: []OwnerReference{{Kind: SourceKindNode, Name: node.Name}},
+				NodeName:   node.Name,
+			})
+		}
+	}
+	return results, nil
+}
+
+// NodePlatforms returns the distinct os/arch platforms of the cluster's nodes, sorted.
+func NodePlatforms(ctx context.Context, clientset *kubernetes.Clientset) ([]string, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	seen := map[string]bool{}
+	var platforms []string
+	for _, node := range nodes.Items {
+		info := node.Status.NodeInfo
+		if info.OperatingSystem == "" || info.Architecture == "" {
+			continue
+		}
+		p := info.OperatingSystem + "/" + info.Architecture
+		if !seen[p] {
+			seen[p] = true
+			platforms = append(platforms, p)
+		}
+	}
+	sort.Strings(platforms)
+	return platforms, nil
+}
+
+// nodeImageName picks the most useful name of a cached node image.
+func nodeImageName(names []string) string {
+	digestName := ""
+	for _, n := range names {
+		if strings.Contains(n, "<none>") {
+			continue
+		}
+		if !strings.Contains(n, "@") {
+			return n
+		}
+		if digestName == "" {
+			digestName = n
+		}
+	}
+	return digestName
+}
+
+// ownerSkipped reports whether a controller, or any controller owning it, is annotated with
+// SkipMirrorAnnotation=true. Results are cached in cache; owners that cannot be read are not skipped.
+func ownerSkipped(ctx context.Context, clientset *kubernetes.Clientset, namespace string, owner metav1.OwnerReference, cache map[string]bool) bool {
+	key := namespace + "/" + owner.Kind + "/" + owner.Name
+	if skip, ok := cache[key]; ok {
+		return skip
+	}
+	meta, err := ownerMeta(ctx, clientset, namespace, owner.Kind, owner.Name)
+	skip := false
+	if err == nil && meta != nil {
+		skip = meta.Annotations[SkipMirrorAnnotation] == "true"
+		if !skip && len(meta.OwnerReferences) > 0 {
+			skip = ownerSkipped(ctx, clientset, namespace, meta.OwnerReferences[0], cache)
+		}
+	}
+	cache[key] = skip
+	return skip
+}
+
+// ownerMeta returns the object metadata of a workload controller, or nil for other kinds.
+func ownerMeta(ctx context.Context, clientset *kubernetes.Clientset, namespace, kind, name string) (*metav1.ObjectMeta, error) {
+	get := metav1.GetOptions{}
+	switch kind {
+	case "ReplicaSet":
+		obj, err := clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, get)
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+	case "Deployment":
+		obj, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, get)
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+	case "StatefulSet":
+		obj, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, get)
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+	case "DaemonSet":
+		obj, err := clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, get)
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+	case "Job":
+		obj, err := clientset.BatchV1().Jobs(namespace).Get(ctx, name, get)
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+	case "CronJob":
+		obj, err := clientset.BatchV1().CronJobs(namespace).Get(ctx, name, get)
+		if err != nil {
+			return nil, err
+		}
+		return &obj.ObjectMeta, nil
+	default:
+		return nil, nil
+	}
+}
+
+// ResolveOwnerChain resolves the controllers owning a pod's direct owner, returning
+// the chain from the top-level owner down to the direct owner.
+func ResolveOwnerChain(ctx context.Context, clientset *kubernetes.Clientset, namespace, kind, name string) []OwnerReference {
+	ctx, span := tracing.Start(ctx, "resolve owner chain",
+		attribute.String("k8s.namespace.name", namespace),
+		attribute.String("krane.owner", kind+"/"+name))
+	defer span.End()
+
+	chain := []OwnerReference{{Kind: kind, Name: name}}
+	for {
+		topKind, topName, err := ResolveTopOwner(ctx, clientset, namespace, chain[0].Kind, chain[0].Name)
+		if err != nil || (topKind == chain[0].Kind && topName == chain[0].Name) {
+			return chain
+		}
+		chain = append([]OwnerReference{{Kind: topKind, Name: topName}}, chain...)
+	}
+}
+
 // ResolveTopOwner resolves top-level owner controllers for common Kubernetes resources.
-func ResolveTopOwner(clientset *kubernetes.Clientset, namespace, kind, name string) (string, string, error) {
+func ResolveTopOwner(ctx context.Context, clientset *kubernetes.Clientset, namespace, kind, name string) (string, string, error) {
 	switch kind {
 	case "ReplicaSet":
-		rs, err := clientset.AppsV1().ReplicaSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		rs, err := clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
 		if err != nil {
 			return kind, name, nil
 		}
@@ -171,7 +590,7 @@ func ResolveTopOwner(clientset *kubernetes.Clientset, namespace, kind, name stri
 		}
 		return kind, name, nil
 	case "Job":
-		job, err := clientset.BatchV1().Jobs(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		job, err := clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
 		if err != nil {
 			return kind, name, nil
 		}
@@ -187,45 +606,16 @@ func ResolveTopOwner(clientset *kubernetes.Clientset, namespace, kind, name stri
 	}
 }
 
-type namespaceMatcher struct {
-	isRegex bool
-	prefix  string
-	re      *regexp.Regexp
-}
-
-// match checks if the given string matches this namespace matcher.
-func (m namespaceMatcher) match(s string) bool {
-	if m.isRegex {
-		return m.re.MatchString(s)
-	}
-	return strings.HasPrefix(s, m.prefix)
-}
-
-// compileNamespaceMatchers compiles namespace patterns into regex or prefix matchers.
-func compileNamespaceMatchers(patterns []string) ([]namespaceMatcher, error) {
-	var matchers []namespaceMatcher
-	for _, p := range patterns {
-		p = strings.TrimSpace(p)
-		if p == "" {
-			continue
-		}
-		re, err := regexp.Compile(p)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "⚠️ invalid namespace regex '%s': %v. Falling back to prefix match.\n", p, err)
-			matchers = append(matchers, namespaceMatcher{isRegex: false, prefix: p})
-			continue
-		}
-		matchers = append(matchers, namespaceMatcher{isRegex: true, re: re})
+// compileNamespaceMatchers compiles namespace patterns (see package match).
+func compileNamespaceMatchers(patterns []string) ([]*match.Matcher, error) {
+	matchers, err := match.CompileAll(patterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace pattern: %w", err)
 	}
-	return matchers, nil
-}
-
-// namespaceMatchesAny checks if the string matches any of the provided matchers.
-func namespaceMatchesAny(s string, matchers []namespaceMatcher) bool {
 	for _, m := range matchers {
-		if m.match(s) {
-			return true
+		if m.Field() != "" {
+			return nil, fmt.Errorf("invalid namespace pattern %q: %s: is only supported for image patterns", m, m.Field())
 		}
 	}
-	return false
+	return matchers, nil
 }
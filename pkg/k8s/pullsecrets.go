@@ -0,0 +1,217 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SecretRef identifies an image pull secret.
+type SecretRef struct {
+	Namespace string
+	Name      string
+}
+
+// String returns the secret as namespace/name.
+func (r SecretRef) String() string {
+	return r.Namespace + "/" + r.Name
+}
+
+// ListImagePullSecrets maps each image of the selected pods to the image pull secrets of
+// the pods using it, including those of the pods' service accounts.
+func ListImagePullSecrets(ctx context.Context, clientset *kubernetes.Clientset, opts DiscoveryOptions) (map[string][]SecretRef, error) {
+	pods, _, err := listPods(ctx, clientset, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceAccounts := map[string][]corev1.LocalObjectReference{} // by namespace/name
+	seen := map[string]map[SecretRef]bool{}
+	out := map[string][]SecretRef{}
+	for i := range pods {
+		pod := &pods[i]
+		secrets := append([]corev1.LocalObjectReference{}, pod.Spec.ImagePullSecrets...)
+
+		saName := pod.Spec.ServiceAccountName
+		if saName == "" {
+			saName = "default"
+		}
+		saKey := pod.Namespace + "/" + saName
+		saSecrets, ok := serviceAccounts[saKey]
+		if !ok {
+			sa, err := clientset.CoreV1().ServiceAccounts(pod.Namespace).Get(ctx, saName, metav1.GetOptions{})
+			if err != nil {
+				opts.log().Debug("could not read service account", "serviceAccount", saKey, "error", err)
+			} else {
+				saSecrets = sa.ImagePullSecrets
+			}
+			serviceAccounts[saKey] = saSecrets
+		}
+		secrets = append(secrets, saSecrets...)
+		if len(secrets) == 0 {
+			continue
+		}
+
+		for _, c := range podContainers(pod, opts.IncludeEphemeral) {
+			if seen[c.image] == nil {
+				seen[c.image] = map[SecretRef]bool{}
+			}
+			for _, s := range secrets {
+				ref := SecretRef{Namespace: pod.Namespace, Name: s.Name}
+				if s.Name == "" || seen[c.image][ref] {
+					continue
+				}
+				seen[c.image][ref] = true
+				out[c.image] = append(out[c.image], ref)
+			}
+		}
+	}
+	return out, nil
+}
+
+// PullSecretKeychains reads the pull secrets of each image once and returns a keychain per
+// image that has at least one usable secret. Secrets that are missing, unreadable or not of
+// a docker config type are logged and skipped.
+func PullSecretKeychains(ctx context.Context, clientset *kubernetes.Clientset, refsByImage map[string][]SecretRef, log *slog.Logger) (map[string]authn.Keychain, error) {
+	if log == nil {
+		log = slog.Default()
+	}
+	secrets := map[SecretRef]*corev1.Secret{} // nil for secrets that could not be used
+	keychains := make(map[string]authn.Keychain, len(refsByImage))
+	for image, refs := range refsByImage {
+		var usable []*corev1.Secret
+		for _, ref := range refs {
+			secret, ok := secrets[ref]
+			if !ok {
+				secret = getPullSecret(ctx, clientset, ref, log)
+				secrets[ref] = secret
+			}
+			if secret != nil {
+				usable = append(usable, secret)
+			}
+		}
+		if len(usable) == 0 {
+			continue
+		}
+		kc, err := NewPullSecretKeychain(usable)
+		if err != nil {
+			return nil, err
+		}
+		keychains[image] = kc
+	}
+	return keychains, nil
+}
+
+// getPullSecret fetches a docker config secret, or returns nil after logging why it cannot be used.
+func getPullSecret(ctx context.Context, clientset *kubernetes.Clientset, ref SecretRef, log *slog.Logger) *corev1.Secret {
+	secret, err := clientset.CoreV1().Secrets(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		log.Warn("could not read image pull secret", "secret", ref.String(), "error", err)
+		return nil
+	}
+	if secret.Type != corev1.SecretTypeDockerConfigJson && secret.Type != corev1.SecretTypeDockercfg {
+		log.Warn("ignoring image pull secret of unsupported type", "secret", ref.String(), "type", string(secret.Type))
+		return nil
+	}
+	return secret
+}
+
+// dockerConfigEntry is the credential of one registry in a docker config.
+type dockerConfigEntry struct {
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	Auth          string `json:"auth"`
+	IdentityToken string `json:"identitytoken"`
+	RegistryToken string `json:"registrytoken"`
+}
+
+// pullSecretEntry is a credential scoped to a registry host and optional repository path.
+type pullSecretEntry struct {
+	host string
+	path string
+	auth authn.AuthConfig
+}
+
+// pullSecretKeychain resolves credentials from parsed image pull secrets. It keeps them in
+// memory only.
+type pullSecretKeychain struct {
+	entries []pullSecretEntry // longest path first
+}
+
+// NewPullSecretKeychain builds a keychain from dockerconfigjson and dockercfg secrets. Keys
+// may carry a scheme, a /v1/ or /v2/ suffix and a repository path, as kubelet accepts them.
+func NewPullSecretKeychain(secrets []*corev1.Secret) (authn.Keychain, error) {
+	kc := &pullSecretKeychain{}
+	for _, secret := range secrets {
+		var auths map[string]dockerConfigEntry
+		switch secret.Type {
+		case corev1.SecretTypeDockerConfigJson:
+			var cfg struct {
+				Auths map[string]dockerConfigEntry `json:"auths"`
+			}
+			if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &cfg); err != nil {
+				return nil, fmt.Errorf("parsing secret %s/%s: %w", secret.Namespace, secret.Name, err)
+			}
+			auths = cfg.Auths
+		case corev1.SecretTypeDockercfg:
+			if err := json.Unmarshal(secret.Data[corev1.DockerConfigKey], &auths); err != nil {
+				return nil, fmt.Errorf("parsing secret %s/%s: %w", secret.Namespace, secret.Name, err)
+			}
+		default:
+			continue
+		}
+		for key, e := range auths {
+			host, path := parseRegistryKey(key)
+			kc.entries = append(kc.entries, pullSecretEntry{host: host, path: path, auth: authn.AuthConfig{
+				Username:      e.Username,
+				Password:      e.Password,
+				Auth:          e.Auth,
+				IdentityToken: e.IdentityToken,
+				RegistryToken: e.RegistryToken,
+			}})
+		}
+	}
+	sort.SliceStable(kc.entries, func(i, j int) bool { return len(kc.entries[i].path) > len(kc.entries[j].path) })
+	return kc, nil
+}
+
+// Resolve implements authn.Keychain.
+func (kc *pullSecretKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	host := target.RegistryStr()
+	repo := ""
+	if r, ok := target.(name.Repository); ok {
+		repo = r.RepositoryStr()
+	}
+	for _, e := range kc.entries {
+		if e.host != host {
+			continue
+		}
+		if e.path == "" || repo == e.path || strings.HasPrefix(repo, e.path+"/") {
+			return authn.FromConfig(e.auth), nil
+		}
+	}
+	return authn.Anonymous, nil
+}
+
+// parseRegistryKey splits a docker config key into the canonical registry host and an
+// optional repository path.
+func parseRegistryKey(key string) (string, string) {
+	key = strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+	key = strings.TrimSuffix(key, "/")
+	key = strings.TrimSuffix(strings.TrimSuffix(key, "/v1"), "/v2")
+	host, path, _ := strings.Cut(key, "/")
+	switch host {
+	case "docker.io", "registry-1.docker.io":
+		host = name.DefaultRegistry
+	}
+	return host, path
+}
@@ -0,0 +1,241 @@
+// Package match implements the pattern language shared by all image and namespace filters.
+//
+// A pattern has the form
+//
+//	[!][field:][i:][kind:]value
+//
+// where kind is one of re (regular expression, unanchored), glob (* and ? wildcards,
+// anchored), prefix or exact. Patterns without a kind are prefix matches. A leading !
+// negates the pattern and i: makes it case-insensitive. For image patterns, field
+// restricts the match to a parsed component of the reference: registry, repository or tag.
+// A field name followed by a port (registry:5000/app) is read as a host, not a field;
+// numeric values of a field need an explicit kind, e.g. tag:exact:1.
+package match
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pattern kinds.
+const (
+	KindRegex  = "re"
+	KindGlob   = "glob"
+	KindPrefix = "prefix"
+	KindExact  = "exact"
+)
+
+// Image reference components that a pattern can be restricted to.
+const (
+	FieldRegistry   = "registry"
+	FieldRepository = "repository"
+	FieldTag        = "tag"
+)
+
+var (
+	kinds  = map[string]bool{KindRegex: true, KindGlob: true, KindPrefix: true, KindExact: true}
+	fields = map[string]bool{FieldRegistry: true, FieldRepository: true, FieldTag: true}
+
+	// portPattern matches the port of a host:port at the start of a value.
+	portPattern = regexp.MustCompile(`^[0-9]+(/|$)`)
+)
+
+// Matcher is a compiled pattern.
+type Matcher struct {
+	pattern string
+	negate  bool
+	field   string
+	kind    string
+	fold    bool
+	value   string
+	re      *regexp.Regexp
+}
+
+// Compile parses a single pattern.
+func Compile(pattern string) (*Matcher, error) {
+	return CompileKind(pattern, KindPrefix)
+}
+
+// CompileKind parses a single pattern whose kind defaults to defaultKind.
+func CompileKind(pattern, defaultKind string) (*Matcher, error) {
+	if !kinds[defaultKind] {
+		return nil, fmt.Errorf("unknown pattern kind: %q", defaultKind)
+	}
+	m := &Matcher{pattern: pattern, kind: defaultKind}
+	rest := strings.TrimSpace(pattern)
+
+	if strings.HasPrefix(rest, "!") {
+		m.negate = true
+		rest = rest[1:]
+	}
+
+	// Consume modifiers until the kind or the first token that is not a modifier
+modifiers:
+	for {
+		idx := strings.Index(rest, ":")
+		if idx == -1 {
+			break
+		}
+		token := rest[:idx]
+		switch {
+		case fields[token] && m.field == "" && !portPattern.MatchString(rest[idx+1:]):
+			m.field = token
+		case token == "i" && !m.fold:
+			m.fold = true
+		case kinds[token]:
+			m.kind = token
+			rest = rest[idx+1:]
+			break modifiers
+		default:
+			break modifiers
+		}
+		rest = rest[idx+1:]
+	}
+
+	if rest == "" {
+		return nil, fmt.Errorf("empty pattern: %q", pattern)
+	}
+	m.value = rest
+
+	switch m.kind {
+	case KindRegex:
+		expr := rest
+		if m.fold {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex in pattern %q: %w", pattern, err)
+		}
+		m.re = re
+	case KindGlob:
+		expr := "^" + globToRegex(rest) + "$"
+		if m.fold {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob in pattern %q: %w", pattern, err)
+		}
+		m.re = re
+	default:
+		if m.fold {
+			m.value = strings.ToLower(m.value)
+		}
+	}
+	return m, nil
+}
+
+// CompileAll parses a list of patterns, skipping empty ones.
+func CompileAll(patterns []string) ([]*Matcher, error) {
+	return CompileAllKind(patterns, KindPrefix)
+}
+
+// CompileAllKind parses a list of patterns whose kind defaults to defaultKind, skipping empty ones.
+func CompileAllKind(patterns []string, defaultKind string) ([]*Matcher, error) {
+	var matchers []*Matcher
+	for _, p := range patterns {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		m, err := CompileKind(p, defaultKind)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	return matchers, nil
+}
+
+// String returns the original pattern.
+func (m *Matcher) String() string {
+	return m.pattern
+}
+
+// Field returns the image component the pattern is restricted to, or "" for the whole value.
+func (m *Matcher) Field() string {
+	return m.field
+}
+
+// Match matches the whole value s. Patterns restricted to a field never match plain values.
+func (m *Matcher) Match(s string) bool {
+	if m.field != "" {
+		return m.negate
+	}
+	return m.matchValue(s) != m.negate
+}
+
+// MatchComponents matches s, or the named component of s when the pattern has a field.
+// A missing component does not match.
+func (m *Matcher) MatchComponents(s string, components map[string]string) bool {
+	if m.field == "" {
+		return m.matchValue(s) != m.negate
+	}
+	v, ok := components[m.field]
+	if !ok {
+		return m.negate
+	}
+	return m.matchValue(v) != m.negate
+}
+
+// matchValue applies the pattern kind to a value, ignoring negation.
+func (m *Matcher) matchValue(s string) bool {
+	switch m.kind {
+	case KindRegex, KindGlob:
+		return m.re.MatchString(s)
+	}
+	if m.fold {
+		s = strings.ToLower(s)
+	}
+	if m.kind == KindExact {
+		return s == m.value
+	}
+	return strings.HasPrefix(s, m.value)
+}
+
+// Any reports whether s matches any of the matchers.
+func Any(matchers []*Matcher, s string) bool {
+	for _, m := range matchers {
+		if m.Match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFields reports whether any matcher is restricted to an image component.
+func HasFields(matchers []*Matcher) bool {
+	for _, m := range matchers {
+		if m.field != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// globToRegex converts a glob into an unanchored regular expression where * matches
+// any sequence (including /) and ? a single character. Character classes are kept.
+func globToRegex(glob string) string {
+	var b strings.Builder
+	inClass := false
+	for _, r := range glob {
+		switch {
+		case inClass:
+			if r == ']' {
+				inClass = false
+			}
+			b.WriteRune(r)
+		case r == '*':
+			b.WriteString(".*")
+		case r == '?':
+			b.WriteString(".")
+		case r == '[':
+			inClass = true
+			b.WriteRune(r)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
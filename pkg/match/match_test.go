@@ -0,0 +1,203 @@
+package match
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		// Patterns without a kind are prefix matches
+		{"nginx", "nginx:1.27", true},
+		{"nginx", "docker.io/nginx:1.27", false},
+		{"prefix:nginx", "nginx-ingress:1.0", true},
+
+		{"exact:nginx:1.27", "nginx:1.27", true},
+		{"exact:nginx:1.27", "nginx:1.27.1", false},
+
+		// Globs are anchored; * crosses path separators
+		{"glob:*log4j*", "docker.io/acme/log4j-app:2.14", true},
+		{"glob:nginx:1.2?", "nginx:1.27", true},
+		{"glob:nginx:1.2?", "nginx:1.270", false},
+		{"glob:nginx:1.[0-2]*", "nginx:1.1", true},
+		{"glob:nginx:1.[0-2]*", "nginx:1.3", false},
+		{"glob:nginx", "docker.io/nginx", false},
+
+		// Regular expressions are unanchored
+		{"re:log4j", "docker.io/acme/log4j-app:2.14", true},
+		{"re:^log4j", "docker.io/acme/log4j-app:2.14", false},
+		{"re:-(rc|beta)[0-9]*$", "app:2.0-rc1", true},
+
+		// Negation inverts the result
+		{"!nginx", "nginx:1.27", false},
+		{"!nginx", "redis:7", true},
+		{"!glob:*:latest", "app:latest", false},
+
+		// i: folds case for every kind
+		{"i:NGINX", "nginx:1.27", true},
+		{"NGINX", "nginx:1.27", false},
+		{"i:exact:Nginx:1.27", "NGINX:1.27", true},
+		{"i:glob:*LOG4J*", "acme/log4j:2", true},
+		{"i:re:LOG4J", "acme/log4j:2", true},
+		{"!i:NGINX", "nginx:1.27", false},
+
+		// A registry host with a port is part of the value, not a field modifier
+		{"registry:5000/app", "registry:5000/app:1.0", true},
+		{"registry:5000/app", "registry:5000/other:1.0", false},
+		{"exact:registry:5000/app:1.0", "registry:5000/app:1.0", true},
+		{"registry:5000", "registry:5000", true},
+
+		// Field patterns never match plain values
+		{"tag:latest", "app:latest", false},
+		{"!tag:latest", "app:latest", true},
+	}
+	for _, tt := range tests {
+		m, err := Compile(tt.pattern)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.pattern, err)
+			continue
+		}
+		if got := m.Match(tt.value); got != tt.want {
+			t.Errorf("%q.Match(%q) = %v, want %v", tt.pattern, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMatchComponents(t *testing.T) {
+	components := map[string]string{
+		FieldRegistry:   "registry.k8s.io",
+		FieldRepository: "ingress-nginx/controller",
+		FieldTag:        "v1.12.3",
+	}
+	const image = "registry.k8s.io/ingress-nginx/controller:v1.12.3"
+
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"registry:exact:registry.k8s.io", true},
+		{"registry:exact:registry.k8s", false},
+		{"registry:registry.k8s", true},
+		{"repository:glob:*/controller", true},
+		{"repository:controller", false},
+		{"tag:re:^v1\\.12\\.", true},
+		{"tag:exact:v1.12", false},
+		{"tag:i:V1.12", true},
+		{"i:tag:V1.12", true},
+		{"!tag:v1.12", false},
+		{"!registry:docker.io", true},
+		// A pattern without a field matches the whole reference
+		{"registry.k8s.io/ingress-nginx", true},
+		{"glob:*:v1.12.3", true},
+	}
+	for _, tt := range tests {
+		m, err := Compile(tt.pattern)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.pattern, err)
+			continue
+		}
+		if got := m.MatchComponents(image, components); got != tt.want {
+			t.Errorf("%q.MatchComponents(%q) = %v, want %v", tt.pattern, image, got, tt.want)
+		}
+	}
+
+	m, err := Compile("tag:latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MatchComponents("app", map[string]string{FieldRegistry: "docker.io"}) {
+		t.Error("a missing component matched")
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		pattern string
+		field   string
+		kind    string
+		negate  bool
+		fold    bool
+		value   string
+	}{
+		{"nginx", "", KindPrefix, false, false, "nginx"},
+		{"!i:tag:exact:Latest", FieldTag, KindExact, true, true, "latest"},
+		{"repository:glob:library/*", FieldRepository, KindGlob, false, false, "library/*"},
+		{"registry:5000/app", "", KindPrefix, false, false, "registry:5000/app"},
+		{"registry:exact:localhost:5000", FieldRegistry, KindExact, false, false, "localhost:5000"},
+		// A kind ends the modifiers; the rest is the value
+		{"exact:tag:1.0", "", KindExact, false, false, "tag:1.0"},
+		{"tag:exact:1", FieldTag, KindExact, false, false, "1"},
+		{"  docker.io/  ", "", KindPrefix, false, false, "docker.io/"},
+	}
+	for _, tt := range tests {
+		m, err := Compile(tt.pattern)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.pattern, err)
+			continue
+		}
+		if m.field != tt.field || m.kind != tt.kind || m.negate != tt.negate || m.fold != tt.fold || m.value != tt.value {
+			t.Errorf("Compile(%q) = field %q kind %q negate %v fold %v value %q, want %q %q %v %v %q",
+				tt.pattern, m.field, m.kind, m.negate, m.fold, m.value, tt.field, tt.kind, tt.negate, tt.fold, tt.value)
+		}
+	}
+
+	for _, pattern := range []string{"", "!", "tag:", "re:(", "i:re:[a-"} {
+		if _, err := Compile(pattern); err == nil {
+			t.Errorf("Compile(%q) succeeded, want an error", pattern)
+		}
+	}
+}
+
+func TestCompileKind(t *testing.T) {
+	m, err := CompileKind("docker.io", KindExact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The allowlist use case: a registry must not match hosts that merely start with it
+	for value, want := range map[string]bool{"docker.io": true, "docker.io.evil.com": false} {
+		if got := m.Match(value); got != want {
+			t.Errorf("exact default: Match(%q) = %v, want %v", value, got, want)
+		}
+	}
+
+	m, err = CompileKind("glob:*.example.com", KindExact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Match("registry.example.com") {
+		t.Error("an explicit kind did not override the default kind")
+	}
+
+	if _, err := CompileKind("nginx", "fuzzy"); err == nil {
+		t.Error("CompileKind accepted an unknown default kind")
+	}
+}
+
+func TestCompileAll(t *testing.T) {
+	matchers, err := CompileAll([]string{"nginx", "", "  ", "glob:*redis*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matchers) != 2 {
+		t.Fatalf("got %d matchers, want empty patterns skipped", len(matchers))
+	}
+	if !Any(matchers, "bitnami/redis:7") || Any(matchers, "postgres:16") {
+		t.Error("Any does not report a match of any matcher")
+	}
+	if HasFields(matchers) {
+		t.Error("HasFields reported a field for plain patterns")
+	}
+
+	matchers, err = CompileAll([]string{"nginx", "tag:latest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !HasFields(matchers) {
+		t.Error("HasFields missed a field pattern")
+	}
+
+	if _, err := CompileAll([]string{"nginx", "re:("}); err == nil {
+		t.Error("CompileAll accepted an invalid pattern")
+	}
+}
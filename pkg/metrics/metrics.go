@@ -0,0 +1,178 @@
+// Package metrics defines the Prometheus metrics recorded by krane and the ways to export them:
+// an HTTP /metrics endpoint, a node_exporter textfile and a Pushgateway.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// Services reported in the service label of API metrics.
+const (
+	ServiceKubernetes = "kubernetes"
+	ServiceRegistry   = "registry"
+)
+
+// Metrics holds the krane collectors and the registry they are registered in.
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	ImagesDiscovered prometheus.Counter
+	ImagesCopied     prometheus.Counter
+	ImagesSkipped    prometheus.Counter
+	ImagesFailed     *prometheus.CounterVec
+	Targets          *prometheus.CounterVec
+	BytesTransferred prometheus.Counter
+	CopyDuration     prometheus.Histogram
+	APIRequests      *prometheus.CounterVec
+	APIDuration      *prometheus.HistogramVec
+}
+
+// New creates the krane metrics in a fresh registry, together with Go and process collectors.
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		ImagesDiscovered: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "krane_images_discovered_total",
+			Help: "Unique images discovered in the cluster after filtering.",
+		}),
+		ImagesCopied: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "krane_images_copied_total",
+			Help: "Images copied to at least one destination registry.",
+		}),
+		ImagesSkipped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "krane_images_skipped_total",
+			Help: "Images skipped because they already exist in every destination.",
+		}),
+		ImagesFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "krane_images_failed_total",
+			Help: "Images that could not be copied to every destination registry, by reason.",
+		}, []string{"reason"}),
+		Targets: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "krane_targets_total",
+			Help: "Image destinations by destination registry and status (pushed, cached, rewritten, skipped, failed).",
+		}, []string{"registry", "status"}),
+		BytesTransferred: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "krane_bytes_transferred_total",
+			Help: "Bytes written to destination registries.",
+		}),
+		CopyDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "krane_copy_duration_seconds",
+			Help:    "Duration of image copies.",
+			Buckets: prometheus.ExponentialBuckets(0.5, 2, 12),
+		}),
+		APIRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "krane_api_requests_total",
+			Help: "Requests to the Kubernetes API, AWS APIs and container registries, by service, operation and HTTP status code.",
+		}, []string{"service", "operation", "code"}),
+		APIDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "krane_api_request_duration_seconds",
+			Help:    "Duration of API and registry requests.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"service", "operation"}),
+	}
+	m.Registry.MustRegister(
+		m.ImagesDiscovered, m.ImagesCopied, m.ImagesSkipped, m.ImagesFailed, m.Targets,
+		m.BytesTransferred, m.CopyDuration, m.APIRequests, m.APIDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// observeRequest records one API request.
+func (m *Metrics) observeRequest(service, operation, code string, d time.Duration) {
+	m.APIRequests.WithLabelValues(service, operation, code).Inc()
+	m.APIDuration.WithLabelValues(service, operation).Observe(d.Seconds())
+}
+
+// Serve exposes the metrics on http://addr/metrics until ctx is done.
+func (m *Metrics) Serve(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{}))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+	go server.Serve(listener)
+	return nil
+}
+
+// WriteTextfile writes the metrics in the text exposition format for the node_exporter
+// textfile collector. The file is replaced atomically.
+func (m *Metrics) WriteTextfile(path string) error {
+	if err := prometheus.WriteToTextfile(path, m.Registry); err != nil {
+		return fmt.Errorf("writing metrics to %s: %w", path, err)
+	}
+	return nil
+}
+
+// Push sends the metrics to a Pushgateway under the given job name, replacing earlier pushes.
+func (m *Metrics) Push(ctx context.Context, url, job string) error {
+	if err := push.New(url, job).Gatherer(m.Registry).PushContext(ctx); err != nil {
+		return fmt.Errorf("pushing metrics to %s: %w", url, err)
+	}
+	return nil
+}
+
+// WrapTransport returns a function wrapping an HTTP transport so that every request
+// is counted under service, with the HTTP method as operation.
+func (m *Metrics) WrapTransport(service string) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &roundTripper{next: next, metrics: m, service: service}
+	}
+}
+
+// roundTripper records metrics for each request it forwards.
+type roundTripper struct {
+	next    http.RoundTripper
+	metrics *Metrics
+	service string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := rt.next.RoundTrip(req)
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	rt.metrics.observeRequest(rt.service, req.Method, code, time.Since(start))
+	return resp, err
+}
+
+// AWSMiddleware is an AWS SDK API option that counts every operation under its
+// service ID (e.g. ecr, sts) and operation name.
+func (m *Metrics) AWSMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("KraneMetrics",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			start := time.Now()
+			out, md, err := next.HandleInitialize(ctx, in)
+			code := "error"
+			if resp, ok := awsmiddleware.GetRawResponse(md).(*smithyhttp.Response); ok && resp != nil {
+				code = strconv.Itoa(resp.StatusCode)
+			}
+			service := awsmiddleware.GetServiceID(ctx)
+			m.observeRequest(strings.ToLower(service), awsmiddleware.GetOperationName(ctx), code, time.Since(start))
+			return out, md, err
+		}), middleware.After)
+}
@@ -0,0 +1,129 @@
+// Package tracing exports OpenTelemetry spans over OTLP/HTTP and instruments the
+// HTTP transports and AWS SDK clients used by krane.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of krane spans.
+const tracerName = "krane"
+
+// Options configures trace export.
+type Options struct {
+	// Endpoint is the OTLP/HTTP collector, as host:port or a URL such as http://localhost:4318.
+	// Empty uses the standard OTEL_EXPORTER_OTLP_* environment variables, and disables
+	// tracing when those are not set either.
+	Endpoint string
+	// Insecure sends spans over plain HTTP when Endpoint is host:port.
+	Insecure bool
+	// ServiceName is reported as service.name (default: krane).
+	ServiceName string
+}
+
+// enabled reports whether an endpoint is configured by opts or the environment.
+func (opts Options) enabled() bool {
+	return opts.Endpoint != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Setup installs a global tracer provider exporting to the configured endpoint and returns
+// a function that flushes pending spans. Without an endpoint spans are not recorded and
+// the returned function does nothing.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	if !opts.enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var exporterOpts []otlptracehttp.Option
+	switch {
+	case strings.Contains(opts.Endpoint, "://"):
+		exporterOpts = append(exporterOpts, otlptracehttp.WithEndpointURL(opts.Endpoint))
+	case opts.Endpoint != "":
+		exporterOpts = append(exporterOpts, otlptracehttp.WithEndpoint(opts.Endpoint))
+		if opts.Insecure {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+		}
+	}
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := opts.ServiceName
+	if serviceName == "" {
+		serviceName = "krane"
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start starts a span as a child of the span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// WrapTransport returns rt with a client span for every request, named after the
+// HTTP method and host.
+func WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(rt, otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		return r.Method + " " + r.URL.Host
+	}))
+}
+
+// AWSMiddleware is an AWS SDK API option that wraps every operation in a client span
+// named service.Operation (e.g. ECR.CreateRepository).
+func AWSMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("KraneTracing",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			service, operation := awsmiddleware.GetServiceID(ctx), awsmiddleware.GetOperationName(ctx)
+			ctx, span := otel.Tracer(tracerName).Start(ctx, service+"."+operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					attribute.String("rpc.system", "aws-api"),
+					attribute.String("rpc.service", service),
+					attribute.String("rpc.method", operation),
+					attribute.String("cloud.region", awsmiddleware.GetRegion(ctx)),
+				))
+			out, md, err := next.HandleInitialize(ctx, in)
+			if resp, ok := awsmiddleware.GetRawResponse(md).(*smithyhttp.Response); ok && resp != nil {
+				span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+			}
+			if requestID, ok := awsmiddleware.GetRequestIDMetadata(md); ok {
+				span.SetAttributes(attribute.String("aws.request_id", requestID))
+			}
+			End(span, err)
+			return out, md, err
+		}), middleware.Before)
+}
@@ -0,0 +1,153 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"krane/pkg/utils"
+
+	"golang.org/x/time/rate"
+)
+
+// maxBandwidthBurst bounds how many bytes may pass at once before the limit applies.
+const maxBandwidthBurst = 256 << 10
+
+// BandwidthLimits are byte rates per second for registry traffic. Zero means unlimited.
+type BandwidthLimits struct {
+	// Total applies to all registry traffic combined.
+	Total int64
+	// Upload applies to traffic with destination registries in both directions, i.e. pushes
+	// as well as reads such as verification and sync checks.
+	Upload int64
+	// Download applies to traffic with all other registries, i.e. the sources.
+	Download int64
+	// IsDestination reports whether a registry host is a destination. When nil, every host
+	// is a source.
+	IsDestination func(host string) bool
+}
+
+// ParseBandwidth parses a byte rate such as "50MiB/s", "10MB/s" or "512K" (per second).
+func ParseBandwidth(spec string) (int64, error) {
+	s := strings.TrimSpace(spec)
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "/s"), "ps")
+	n, err := utils.ParseBytes(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bandwidth %q: expected a size per second such as 50MiB/s", spec)
+	}
+	return n, nil
+}
+
+// newBandwidthLimiter returns a limiter for bytesPerSecond, or nil when it is unlimited.
+func newBandwidthLimiter(bytesPerSecond int64) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), int(min(bytesPerSecond, maxBandwidthBurst)))
+}
+
+// NewBandwidthLimitedTransport returns a transport that throttles request and response
+// bodies to limits, choosing the upload or download limit by the registry host. The limits
+// are shared by every request made through the transport, so passing one transport to all
+// workers caps the aggregate rate.
+func NewBandwidthLimitedTransport(next http.RoundTripper, limits BandwidthLimits) http.RoundTripper {
+	total := newBandwidthLimiter(limits.Total)
+	t := &bandwidthTransport{next: next, isDestination: limits.IsDestination}
+	for _, l := range []*rate.Limiter{total, newBandwidthLimiter(limits.Upload)} {
+		if l != nil {
+			t.destination = append(t.destination, l)
+		}
+	}
+	for _, l := range []*rate.Limiter{total, newBandwidthLimiter(limits.Download)} {
+		if l != nil {
+			t.source = append(t.source, l)
+		}
+	}
+	if len(t.destination) == 0 && len(t.source) == 0 {
+		return next
+	}
+	return t
+}
+
+// bandwidthTransport wraps bodies in readers that wait for their bytes on shared limiters.
+type bandwidthTransport struct {
+	next          http.RoundTripper
+	isDestination func(host string) bool
+	destination   []*rate.Limiter
+	source        []*rate.Limiter
+}
+
+// limiters returns the limiters for the registry a request is made to.
+func (t *bandwidthTransport) limiters(req *http.Request) []*rate.Limiter {
+	// Redirects, e.g. to a registry's blob storage, count against the registry that sent them
+	for req.Response != nil && req.Response.Request != nil {
+		req = req.Response.Request
+	}
+	if t.isDestination != nil && t.isDestination(req.URL.Host) {
+		return t.destination
+	}
+	return t.source
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *bandwidthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiters := t.limiters(req)
+	if len(limiters) == 0 {
+		return t.next.RoundTrip(req)
+	}
+	ctx := req.Context()
+	if req.Body != nil && req.Body != http.NoBody {
+		req = req.Clone(ctx)
+		req.Body = &throttledReader{rc: req.Body, ctx: ctx, limiters: limiters}
+		if getBody := req.GetBody; getBody != nil {
+			req.GetBody = func() (io.ReadCloser, error) {
+				body, err := getBody()
+				if err != nil {
+					return nil, err
+				}
+				return &throttledReader{rc: body, ctx: ctx, limiters: limiters}, nil
+			}
+		}
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		resp.Body = &throttledReader{rc: resp.Body, ctx: ctx, limiters: limiters}
+	}
+	return resp, nil
+}
+
+// throttledReader reads at most one burst at a time and waits until every limiter allows
+// the bytes read.
+type throttledReader struct {
+	rc       io.ReadCloser
+	ctx      context.Context
+	limiters []*rate.Limiter
+}
+
+// Read implements io.Reader.
+func (r *throttledReader) Read(p []byte) (int, error) {
+	for _, l := range r.limiters {
+		if burst := l.Burst(); len(p) > burst {
+			p = p[:burst]
+		}
+	}
+	n, err := r.rc.Read(p)
+	if n > 0 {
+		for _, l := range r.limiters {
+			if werr := l.WaitN(r.ctx, n); werr != nil {
+				return n, werr
+			}
+		}
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (r *throttledReader) Close() error {
+	return r.rc.Close()
+}
@@ -0,0 +1,322 @@
+package transfer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/cache"
+	"github.com/google/go-containerregistry/pkg/v1/partial"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// BlobCache is a content-addressed on-disk cache of compressed layers, shared by all
+// mirror operations and kept across runs. Blobs are stored as blobs/sha256/<hex>, only
+// after their digest has been verified, with their media type in mediatypes/sha256/<hex>.
+// The least recently used blobs are evicted when the cache grows beyond its size limit.
+// It implements cache.Cache.
+type BlobCache struct {
+	dir     string
+	maxSize int64
+
+	mu     sync.Mutex // serializes eviction
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// BlobCacheStats reports cache usage.
+type BlobCacheStats struct {
+	Hits   int64
+	Misses int64
+	Blobs  int
+	Size   int64
+}
+
+// staleIncoming is the age after which partial downloads left by an aborted run are removed.
+const staleIncoming = 24 * time.Hour
+
+// NewBlobCache opens or creates a cache in dir. A maxSize of 0 means no limit.
+func NewBlobCache(dir string, maxSize int64) (*BlobCache, error) {
+	c := &BlobCache{dir: dir, maxSize: maxSize}
+	for _, d := range []string{c.blobDir(), c.mediaTypeDir()} {
+		if err := os.MkdirAll(d, 0o700); err != nil {
+			return nil, fmt.Errorf("creating blob cache: %w", err)
+		}
+	}
+	stale, _ := filepath.Glob(filepath.Join(dir, "incoming-*"))
+	for _, p := range stale {
+		if fi, err := os.Stat(p); err == nil && time.Since(fi.ModTime()) > staleIncoming {
+			os.Remove(p)
+		}
+	}
+	return c, c.evict("")
+}
+
+// blobDir is where verified sha256 blobs are stored.
+func (c *BlobCache) blobDir() string {
+	return filepath.Join(c.dir, "blobs", "sha256")
+}
+
+// mediaTypeDir is where the media types of stored blobs are recorded.
+func (c *BlobCache) mediaTypeDir() string {
+	return filepath.Join(c.dir, "mediatypes", "sha256")
+}
+
+// path returns the file of a blob, or false for unsupported digest algorithms.
+func (c *BlobCache) path(h v1.Hash) (string, bool) {
+	if h.Algorithm != "sha256" {
+		return "", false
+	}
+	return filepath.Join(c.blobDir(), h.Hex), true
+}
+
+// Put returns l wrapped so that reading its compressed content stores it in the cache.
+// Misses are counted when the content is read, not for layers that are never fetched.
+func (c *BlobCache) Put(l v1.Layer) (v1.Layer, error) {
+	return &cachingLayer{Layer: l, cache: c}, nil
+}
+
+// Get returns the cached layer with compressed digest h, or cache.ErrNotFound.
+// Only the compressed content, its digest, size and media type are known for cached layers.
+func (c *BlobCache) Get(h v1.Hash) (v1.Layer, error) {
+	p, ok := c.path(h)
+	if !ok {
+		return nil, cache.ErrNotFound
+	}
+	// Opened right away so that eviction by a concurrent Put cannot remove the blob before
+	// it is read; a blob that is already gone is a miss and gets fetched from the source
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, cache.ErrNotFound
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, cache.ErrNotFound
+	}
+	// The modification time doubles as the last access time for LRU eviction
+	now := time.Now()
+	_ = os.Chtimes(p, now, now)
+	// Blobs cached without a media type were Docker layers
+	mediaType := types.DockerLayer
+	if mt, err := os.ReadFile(filepath.Join(c.mediaTypeDir(), h.Hex)); err == nil && len(mt) > 0 {
+		mediaType = types.MediaType(mt)
+	}
+	return partial.CompressedToLayer(&cachedBlob{path: p, file: f, digest: h, size: fi.Size(), mediaType: mediaType, cache: c})
+}
+
+// Delete removes a blob from the cache.
+func (c *BlobCache) Delete(h v1.Hash) error {
+	p, ok := c.path(h)
+	if !ok {
+		return cache.ErrNotFound
+	}
+	os.Remove(filepath.Join(c.mediaTypeDir(), h.Hex))
+	if err := os.Remove(p); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cache.ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Stats returns the hit and miss counts of this process and the current cache contents.
+// Only layers whose content was read count as hits or misses.
+func (c *BlobCache) Stats() BlobCacheStats {
+	s := BlobCacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+	entries, _ := os.ReadDir(c.blobDir())
+	for _, e := range entries {
+		if fi, err := e.Info(); err == nil && fi.Mode().IsRegular() {
+			s.Blobs++
+			s.Size += fi.Size()
+		}
+	}
+	return s
+}
+
+// add moves a verified temporary file into the cache, records its media type and evicts
+// old blobs if needed.
+func (c *BlobCache) add(tmp string, h v1.Hash, mediaType types.MediaType) error {
+	p, ok := c.path(h)
+	if !ok {
+		os.Remove(tmp)
+		return nil
+	}
+	// Written first so a blob is never served with a missing media type
+	if mediaType != "" {
+		if err := os.WriteFile(filepath.Join(c.mediaTypeDir(), h.Hex), []byte(mediaType), 0o600); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return c.evict(p)
+}
+
+// evict removes the least recently used blobs until the cache fits in maxSize, never
+// removing keep, the blob that was just added.
+func (c *BlobCache) evict(keep string) error {
+	if c.maxSize <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.blobDir())
+	if err != nil {
+		return err
+	}
+	type blob struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var blobs []blob
+	var total int64
+	for _, e := range entries {
+		fi, err := e.Info()
+		if err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		blobs = append(blobs, blob{path: filepath.Join(c.blobDir(), e.Name()), size: fi.Size(), modTime: fi.ModTime()})
+		total += fi.Size()
+	}
+	sort.Slice(blobs, func(i, j int) bool { return blobs[i].modTime.Before(blobs[j].modTime) })
+	for _, b := range blobs {
+		if total <= c.maxSize {
+			break
+		}
+		if b.path == keep {
+			continue
+		}
+		// Readers that already opened the blob keep their file handle
+		if err := os.Remove(b.path); err == nil || errors.Is(err, os.ErrNotExist) {
+			total -= b.size
+			os.Remove(filepath.Join(c.mediaTypeDir(), filepath.Base(b.path)))
+		}
+	}
+	return nil
+}
+
+// cachedBlob is a compressed layer read from the cache. It holds the blob open from Get
+// until the first read; an unread file is closed when the layer is garbage collected.
+type cachedBlob struct {
+	path      string
+	digest    v1.Hash
+	size      int64
+	mediaType types.MediaType
+	cache     *BlobCache
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Digest and Size implement partial.CompressedLayer.
+func (b *cachedBlob) Digest() (v1.Hash, error) { return b.digest, nil }
+func (b *cachedBlob) Size() (int64, error)     { return b.size, nil }
+
+// Compressed returns the blob opened by Get, or reopens it when it is read again, counting a hit.
+func (b *cachedBlob) Compressed() (io.ReadCloser, error) {
+	b.mu.Lock()
+	f := b.file
+	b.file = nil
+	b.mu.Unlock()
+	if f == nil {
+		var err error
+		if f, err = os.Open(b.path); err != nil {
+			return nil, fmt.Errorf("reading cached blob %s: %w", b.digest, err)
+		}
+	}
+	b.cache.hits.Add(1)
+	return f, nil
+}
+
+// MediaType returns the media type the blob was cached with.
+func (b *cachedBlob) MediaType() (types.MediaType, error) {
+	return b.mediaType, nil
+}
+
+// cachingLayer stores the compressed content of a layer in the cache while it is read.
+type cachingLayer struct {
+	v1.Layer
+	cache *BlobCache
+}
+
+// Compressed returns the layer content, writing it to a temporary file that is added to
+// the cache once it has been read completely and its digest matches.
+func (l *cachingLayer) Compressed() (io.ReadCloser, error) {
+	digest, err := l.Layer.Digest()
+	if err != nil {
+		return nil, err
+	}
+	// Without a media type the blob is not cached; it could not be served correctly
+	mediaType, _ := l.Layer.MediaType()
+	rc, err := l.Layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	l.cache.misses.Add(1)
+	tmp, err := os.CreateTemp(l.cache.dir, "incoming-")
+	if err != nil || mediaType == "" {
+		// Caching is best effort; serve the layer uncached
+		if tmp != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+		return rc, nil
+	}
+	return &teeReadCloser{rc: rc, tmp: tmp, hasher: sha256.New(), digest: digest, mediaType: mediaType, cache: l.cache}, nil
+}
+
+// teeReadCloser copies what is read into a temporary file and a digest.
+type teeReadCloser struct {
+	rc        io.ReadCloser
+	tmp       *os.File
+	hasher    hash.Hash
+	digest    v1.Hash
+	mediaType types.MediaType
+	cache     *BlobCache
+	eof       bool
+	failed    bool
+}
+
+// Read implements io.Reader.
+func (t *teeReadCloser) Read(p []byte) (int, error) {
+	n, err := t.rc.Read(p)
+	if n > 0 && !t.failed {
+		t.hasher.Write(p[:n])
+		if _, werr := t.tmp.Write(p[:n]); werr != nil {
+			t.failed = true
+		}
+	}
+	if err == io.EOF {
+		t.eof = true
+	}
+	return n, err
+}
+
+// Close closes the source and keeps the temporary file only if it holds the whole blob.
+func (t *teeReadCloser) Close() error {
+	err := t.rc.Close()
+	t.tmp.Close()
+	if t.eof && !t.failed && hex.EncodeToString(t.hasher.Sum(nil)) == t.digest.Hex {
+		_ = t.cache.add(t.tmp.Name(), t.digest, t.mediaType)
+	} else {
+		os.Remove(t.tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,28 @@
+package transfer
+
+import (
+	"github.com/google/go-containerregistry/pkg/authn"
+)
+
+// staticKeychain resolves fixed credentials per registry host.
+type staticKeychain struct {
+	auths    map[string]authn.Authenticator
+	fallback authn.Keychain
+}
+
+// NewStaticKeychain returns a keychain that uses the given credentials for their
+// registry hosts and defers to fallback for any other registry.
+func NewStaticKeychain(auths map[string]authn.Authenticator, fallback authn.Keychain) authn.Keychain {
+	return &staticKeychain{auths: auths, fallback: fallback}
+}
+
+// Resolve implements authn.Keychain.
+func (k *staticKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	if auth, ok := k.auths[target.RegistryStr()]; ok {
+		return auth, nil
+	}
+	if k.fallback == nil {
+		return authn.Anonymous, nil
+	}
+	return k.fallback.Resolve(target)
+}
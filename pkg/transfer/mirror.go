@@ -2,48 +2,368 @@ package transfer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
 	"strings"
+	"sync"
+
+	"krane/pkg/tracing"
 
 	"github.com/google/go-containerregistry/pkg/authn"
-	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/cache"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-// Mirror copies an image from source to destination registry using crane.
-// Preserves multi-arch manifests and handles platform-specific copying.
-func Mirror(ctx context.Context, srcRef, dstRef, platform string) error {
+// Options configures a mirror operation.
+type Options struct {
+	// Platforms limits the copy to these platforms; empty keeps all of them. A single
+	// platform is copied as a plain image, several as a filtered multi-arch index.
+	Platforms []v1.Platform
+	// SourceKeychain authenticates against the source registry (default: local Docker config).
+	SourceKeychain authn.Keychain
+	// DestinationKeychain authenticates against destination registries (default: local Docker config).
+	DestinationKeychain authn.Keychain
+	// Progress, when set, is updated with layer and byte counts while pushing.
+	Progress *Progress
+	// Logger receives transfer log records (default: slog.Default()).
+	Logger *slog.Logger
+	// Transport is used for registry requests (default: remote.DefaultTransport).
+	Transport http.RoundTripper
+	// BlobCache, when set, stores source layers so they are downloaded once across
+	// destinations, images and runs. Without it, a temporary cache is used for several destinations.
+	BlobCache *BlobCache
+	// Mounts, when set, is shared by mirror operations of a run so blobs already uploaded to
+	// a registry are mounted into other repositories instead of being sent again.
+	Mounts *MountIndex
+	// Registries holds per-registry settings: insecure registries are reached over plain
+	// HTTP when needed, and source images are pulled from configured mirrors first.
+	Registries Registries
+}
+
+// DestinationError is the error of one destination that Mirror could not push to. Mirror
+// joins them, so the other destinations of the call were written.
+type DestinationError struct {
+	Destination string
+	Err         error
+}
+
+func (e *DestinationError) Error() string {
+	return fmt.Sprintf("pushing %s: %v", e.Destination, e.Err)
+}
+
+func (e *DestinationError) Unwrap() error {
+	return e.Err
+}
+
+// FailedDestinations returns the destinations of a Mirror error that could not be pushed to,
+// mapped to their error. It is empty when err did not come from pushing, e.g. when the source
+// could not be read, in which case no destination was written.
+func FailedDestinations(err error) map[string]error {
+	failed := map[string]error{}
+	var walk func(error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case *DestinationError:
+			failed[e.Destination] = e.Err
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				walk(err)
+			}
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		}
+	}
+	walk(err)
+	return failed
+}
+
+// remoteOptions returns the registry options shared by pulls and pushes.
+func (opts Options) remoteOptions(ctx context.Context, keychain authn.Keychain) []remote.Option {
+	remoteOpts := []remote.Option{remote.WithAuthFromKeychain(keychain), remote.WithContext(ctx)}
+	if opts.Transport != nil {
+		remoteOpts = append(remoteOpts, remote.WithTransport(opts.Transport))
+	}
+	return remoteOpts
+}
+
+// levelTrace is the log level of per-layer records, below debug.
+const levelTrace = slog.LevelDebug - 4
+
+// layerJobs is the number of layers uploaded concurrently per destination.
+const layerJobs = 4
+
+// Mirror copies an image from source to one or more destination references.
+// The source manifest is resolved once; with several destinations, layers are staged
+// in a temporary on-disk cache so each blob is only downloaded from the source once.
+// Preserves multi-arch manifests unless platforms are given.
+func Mirror(ctx context.Context, srcRef string, dstRefs []string, opts Options) error {
 	srcRef = normalizeImageReference(srcRef)
+	src, err := opts.Registries.ParseReference(srcRef)
+	if err != nil {
+		return err
+	}
+	candidates, err := opts.Registries.pullCandidates(src)
+	if err != nil {
+		return err
+	}
+
+	dsts := make([]name.Reference, 0, len(dstRefs))
+	for _, d := range dstRefs {
+		ref, err := name.ParseReference(d)
+		if err != nil {
+			return fmt.Errorf("parsing destination reference %s: %w", d, err)
+		}
+		dsts = append(dsts, ref)
+	}
+
+	log := opts.Logger
+	if log == nil {
+		log = slog.Default()
+	}
+
+	srcKeychain := opts.SourceKeychain
+	if srcKeychain == nil {
+		srcKeychain = authn.DefaultKeychain
+	}
+	dstKeychain := opts.DestinationKeychain
+	if dstKeychain == nil {
+		dstKeychain = authn.DefaultKeychain
+	}
 
-	opts := []crane.Option{
-		crane.WithAuthFromKeychain(authn.DefaultKeychain),
-		crane.WithContext(ctx),
+	pullOpts := opts.remoteOptions(ctx, srcKeychain)
+	if len(opts.Platforms) == 1 {
+		pullOpts = append(pullOpts, remote.WithPlatform(opts.Platforms[0]))
 	}
 
-	if platform != "" {
-		if err := validatePlatform(platform); err != nil {
-			return err
+	puller, err := remote.NewPuller(pullOpts...)
+	if err != nil {
+		return err
+	}
+	getCtx, span := tracing.Start(ctx, "resolve source", attribute.String("krane.image", srcRef))
+	desc, from, err := getFirst(getCtx, puller, candidates, log)
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("fetching %s: %w", srcRef, err)
+	}
+	log.Debug("resolved source", "image", srcRef, "from", from.String(), "digest", desc.Digest.String(), "mediaType", string(desc.MediaType))
+
+	var layerCache cache.Cache
+	switch {
+	case opts.BlobCache != nil:
+		layerCache = opts.BlobCache
+	case len(dsts) > 1:
+		dir, err := os.MkdirTemp("", "krane-layers-")
+		if err != nil {
+			return fmt.Errorf("creating layer cache: %w", err)
 		}
-		parts := strings.SplitN(platform, "/", 2)
-		opts = append(opts, crane.WithPlatform(&v1.Platform{
-			OS:           parts[0],
-			Architecture: parts[1],
-		}))
+		defer os.RemoveAll(dir)
+		layerCache = cache.NewFilesystemCache(dir)
 	}
 
-	return crane.Copy(srcRef, dstRef, opts...)
+	artifact, err := resolveArtifact(desc, opts.Platforms, layerCache)
+	if err != nil {
+		return err
+	}
+
+	layers, err := artifactLayers(artifact)
+	if err != nil {
+		return err
+	}
+
+	progress := opts.Progress
+	if progress == nil {
+		progress = &Progress{}
+	}
+	progress.start()
+	updates := make(chan v1.Update, 64)
+	tracked := make(chan struct{})
+	go progress.track(updates, tracked)
+	defer func() {
+		close(updates)
+		<-tracked
+	}()
+
+	pusher, err := remote.NewPusher(append(opts.remoteOptions(ctx, dstKeychain), remote.WithProgress(updates))...)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, dst := range dsts {
+		log.Debug("pushing", "image", srcRef, "destination", dst.String(), "layers", len(layers))
+		progress.layersTotal.Add(int64(len(layers)))
+		if err := pushArtifact(ctx, pusher, dst, artifact, layers, opts.Mounts, progress, log); err != nil {
+			errs = append(errs, &DestinationError{Destination: dstRefs[i], Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pushArtifact uploads the layers and then the manifest of artifact to dst.
+func pushArtifact(ctx context.Context, pusher *remote.Pusher, dst name.Reference, artifact remote.Taggable, layers []v1.Layer, mounts *MountIndex, progress *Progress, log *slog.Logger) (err error) {
+	ctx, span := tracing.Start(ctx, "push destination",
+		attribute.String("krane.destination", dst.String()),
+		attribute.Int("krane.layers", len(layers)))
+	defer func() { tracing.End(span, err) }()
+
+	if err := uploadLayers(ctx, pusher, dst.Context(), layers, mounts, progress, log); err != nil {
+		return err
+	}
+	return pusher.Push(ctx, dst, artifact)
 }
 
-// validatePlatform validates the platform format (os/arch).
-func validatePlatform(platform string) error {
-	if strings.Contains(platform, ",") {
-		return fmt.Errorf("multiple platforms not supported: %s", platform)
+// artifactLayers returns the distinct layers of an image or of every image in an index.
+func artifactLayers(artifact remote.Taggable) ([]v1.Layer, error) {
+	seen := map[v1.Hash]bool{}
+	var layers []v1.Layer
+	var collect func(t remote.Taggable) error
+	collect = func(t remote.Taggable) error {
+		switch a := t.(type) {
+		case v1.Image:
+			ls, err := a.Layers()
+			if err != nil {
+				return err
+			}
+			for _, l := range ls {
+				d, err := l.Digest()
+				if err != nil {
+					return err
+				}
+				if !seen[d] {
+					seen[d] = true
+					layers = append(layers, l)
+				}
+			}
+		case v1.ImageIndex:
+			manifest, err := a.IndexManifest()
+			if err != nil {
+				return err
+			}
+			for _, m := range manifest.Manifests {
+				var child remote.Taggable
+				switch {
+				case m.MediaType.IsImage():
+					child, err = a.Image(m.Digest)
+				case m.MediaType.IsIndex():
+					child, err = a.ImageIndex(m.Digest)
+				default:
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				if err := collect(child); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
 	}
-	parts := strings.SplitN(platform, "/", 2)
-	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
-		return fmt.Errorf("invalid platform format, expected os/arch: %s", platform)
+	if err := collect(artifact); err != nil {
+		return nil, fmt.Errorf("listing layers: %w", err)
+	}
+	return layers, nil
+}
+
+// uploadLayers uploads layers to repo with limited concurrency, counting each finished layer.
+// Layers that already exist in the repository are counted without being uploaded, and layers
+// recorded in mounts are mounted from another repository of the registry when possible.
+// Non-distributable (foreign) layers, such as Windows base layers, are skipped like
+// remote.Write does; clients fetch them from the URLs in the manifest.
+func uploadLayers(ctx context.Context, pusher *remote.Pusher, repo name.Repository, layers []v1.Layer, mounts *MountIndex, progress *Progress, log *slog.Logger) error {
+	sem := make(chan struct{}, layerJobs)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, l := range layers {
+		if mt, err := l.MediaType(); err == nil && !mt.IsDistributable() {
+			progress.layersDone.Add(1)
+			if digest, err := l.Digest(); err == nil {
+				log.Log(ctx, levelTrace, "skipping foreign layer", "repository", repo.String(), "digest", digest.String(), "mediaType", string(mt))
+			}
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(l v1.Layer) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := pusher.Upload(ctx, repo, mounts.mountable(l, repo)); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return
+			}
+			progress.layersDone.Add(1)
+			digest, err := l.Digest()
+			if err != nil {
+				return
+			}
+			if mounts != nil {
+				mounts.Add(digest, repo)
+			}
+			log.Log(ctx, levelTrace, "layer done", "repository", repo.String(), "digest", digest.String())
+		}(l)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// resolveArtifact turns a fetched descriptor into the object to push, flattening to a
+// single image for one platform, filtering indexes for several and wrapping layers in c when set.
+func resolveArtifact(desc *remote.Descriptor, platforms []v1.Platform, c cache.Cache) (remote.Taggable, error) {
+	switch {
+	case len(platforms) == 1 || desc.MediaType.IsImage():
+		img, err := desc.Image()
+		if err != nil {
+			return nil, fmt.Errorf("resolving image: %w", err)
+		}
+		if c != nil {
+			img = cache.Image(img, c)
+		}
+		return img, nil
+	case desc.MediaType.IsIndex():
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return nil, fmt.Errorf("resolving index: %w", err)
+		}
+		if c != nil {
+			idx = cache.ImageIndex(idx, c)
+		}
+		if len(platforms) > 1 {
+			return filterIndex(idx, platforms)
+		}
+		return idx, nil
+	default:
+		// Non-image artifacts are copied as-is
+		return desc, nil
+	}
+}
+
+// getFirst fetches the first of refs that can be resolved, so that mirrors are preferred
+// and the upstream reference, which comes last, is used when none of them has the image.
+func getFirst(ctx context.Context, puller *remote.Puller, refs []name.Reference, log *slog.Logger) (*remote.Descriptor, name.Reference, error) {
+	for _, ref := range refs[:len(refs)-1] {
+		desc, err := puller.Get(ctx, ref)
+		if err == nil {
+			return desc, ref, nil
+		}
+		if ctx.Err() != nil {
+			return nil, nil, err
+		}
+		log.Debug("mirror unavailable, trying next", "mirror", ref.String(), "error", err)
 	}
-	return nil
+	upstream := refs[len(refs)-1]
+	desc, err := puller.Get(ctx, upstream)
+	return desc, upstream, err
 }
 
 // normalizeImageReference adds docker.io prefix if no registry is specified.
@@ -0,0 +1,56 @@
+package transfer
+
+import (
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// MountIndex records which repository each blob was uploaded to during a run, so later
+// pushes of the same blob to another repository on the same registry can mount it
+// instead of sending it again. It is safe for concurrent use.
+type MountIndex struct {
+	mu    sync.Mutex
+	repos map[v1.Hash]map[string]name.Repository // digest -> registry -> repository
+}
+
+// NewMountIndex creates an empty index.
+func NewMountIndex() *MountIndex {
+	return &MountIndex{repos: map[v1.Hash]map[string]name.Repository{}}
+}
+
+// Add records that repo holds the blob with the given digest.
+func (m *MountIndex) Add(digest v1.Hash, repo name.Repository) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	byRegistry, ok := m.repos[digest]
+	if !ok {
+		byRegistry = map[string]name.Repository{}
+		m.repos[digest] = byRegistry
+	}
+	if _, ok := byRegistry[repo.RegistryStr()]; !ok {
+		byRegistry[repo.RegistryStr()] = repo
+	}
+}
+
+// mountable wraps l so that pushing it to repo mounts it from another repository of the
+// same registry that already holds it. Registries that do not support mounting fall back
+// to a regular upload.
+func (m *MountIndex) mountable(l v1.Layer, repo name.Repository) v1.Layer {
+	if m == nil {
+		return l
+	}
+	digest, err := l.Digest()
+	if err != nil {
+		return l
+	}
+	m.mu.Lock()
+	src, ok := m.repos[digest][repo.RegistryStr()]
+	m.mu.Unlock()
+	if !ok || src.String() == repo.String() {
+		return l
+	}
+	return &remote.MountableLayer{Layer: l, Reference: src.Digest(digest.String())}
+}
@@ -0,0 +1,84 @@
+package transfer
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+)
+
+// attestationReferenceAnnotation links an attestation manifest to the image it describes.
+const attestationReferenceAnnotation = "vnd.docker.reference.digest"
+
+// ParsePlatforms parses a comma-separated list of os/arch[/variant][:os.version] platforms.
+func ParsePlatforms(s string) ([]v1.Platform, error) {
+	var platforms []v1.Platform
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		p, err := v1.ParsePlatform(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid platform %s: %w", part, err)
+		}
+		if p.OS == "" || p.Architecture == "" {
+			return nil, fmt.Errorf("invalid platform format, expected os/arch[/variant][:os.version]: %s", part)
+		}
+		platforms = append(platforms, *p)
+	}
+	if len(platforms) == 0 {
+		return nil, fmt.Errorf("no platforms given")
+	}
+	return platforms, nil
+}
+
+// FormatPlatforms returns platforms as a comma-separated list.
+func FormatPlatforms(platforms []v1.Platform) string {
+	parts := make([]string, 0, len(platforms))
+	for _, p := range platforms {
+		parts = append(parts, p.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+// matchesPlatforms reports whether p satisfies any of the requested platforms.
+// Fields left empty in a requested platform match any value.
+func matchesPlatforms(p v1.Platform, platforms []v1.Platform) bool {
+	for _, spec := range platforms {
+		if p.Satisfies(spec) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterIndex removes manifests for other platforms from idx. Attestation manifests are
+// kept only when the image they describe is kept.
+func filterIndex(idx v1.ImageIndex, platforms []v1.Platform) (v1.ImageIndex, error) {
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	keep := map[v1.Hash]bool{}
+	for _, m := range manifest.Manifests {
+		if m.Platform != nil && matchesPlatforms(*m.Platform, platforms) {
+			keep[m.Digest] = true
+		}
+	}
+	if len(keep) == 0 {
+		return nil, fmt.Errorf("no manifest matches platforms %s", FormatPlatforms(platforms))
+	}
+
+	return mutate.RemoveManifests(idx, func(desc v1.Descriptor) bool {
+		if keep[desc.Digest] {
+			return false
+		}
+		if ref, ok := desc.Annotations[attestationReferenceAnnotation]; ok {
+			h, err := v1.NewHash(ref)
+			return err != nil || !keep[h]
+		}
+		return desc.Platform != nil
+	}), nil
+}
@@ -0,0 +1,68 @@
+package transfer
+
+import (
+	"sync/atomic"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// Progress tracks the transfer of one mirror operation. It is safe for concurrent use;
+// Mirror updates it while callers read snapshots.
+type Progress struct {
+	started     atomic.Int64
+	layersTotal atomic.Int64
+	layersDone  atomic.Int64
+	bytesTotal  atomic.Int64
+	bytesDone   atomic.Int64
+}
+
+// ProgressSnapshot is a point-in-time view of a Progress.
+type ProgressSnapshot struct {
+	LayersDone  int
+	LayersTotal int
+	BytesDone   int64
+	BytesTotal  int64
+	Elapsed     time.Duration
+}
+
+// Snapshot returns the current counters.
+func (p *Progress) Snapshot() ProgressSnapshot {
+	s := ProgressSnapshot{
+		LayersDone:  int(p.layersDone.Load()),
+		LayersTotal: int(p.layersTotal.Load()),
+		BytesDone:   p.bytesDone.Load(),
+		BytesTotal:  p.bytesTotal.Load(),
+	}
+	if started := p.started.Load(); started != 0 {
+		s.Elapsed = time.Since(time.Unix(0, started))
+	}
+	return s
+}
+
+// Rate returns the average transfer rate in bytes per second.
+func (s ProgressSnapshot) Rate() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.BytesDone) / s.Elapsed.Seconds()
+}
+
+// start records the start time if it is not set yet.
+func (p *Progress) start() {
+	p.started.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+// track consumes registry progress updates until updates is closed. Updates carry
+// cumulative totals for one pusher, so they are added on top of base.
+func (p *Progress) track(updates <-chan v1.Update, done chan<- struct{}) {
+	baseTotal, baseDone := p.bytesTotal.Load(), p.bytesDone.Load()
+	for u := range updates {
+		if u.Error != nil {
+			continue
+		}
+		p.bytesTotal.Store(baseTotal + u.Total)
+		p.bytesDone.Store(baseDone + u.Complete)
+	}
+	close(done)
+}
@@ -0,0 +1,79 @@
+package transfer
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"golang.org/x/time/rate"
+)
+
+// RegistryHost returns the canonical host of a registry name as used in registry
+// requests, e.g. index.docker.io for docker.io.
+func RegistryHost(registry string) (string, error) {
+	reg, err := name.NewRegistry(registry)
+	if err != nil {
+		return "", fmt.Errorf("invalid registry %q: %w", registry, err)
+	}
+	return reg.RegistryStr(), nil
+}
+
+// ImageRegistryHost returns the canonical registry host of an image reference.
+func ImageRegistryHost(image string) (string, error) {
+	ref, err := name.ParseReference(normalizeImageReference(image))
+	if err != nil {
+		return "", fmt.Errorf("parsing reference %s: %w", image, err)
+	}
+	return ref.Context().RegistryStr(), nil
+}
+
+// ParseRate parses a request rate of the form N/period (e.g. 10/s, 100/6h) or N
+// (per second) into a token bucket that allows bursts of N requests.
+func ParseRate(spec string) (*rate.Limiter, error) {
+	count, period := spec, "1s"
+	if i := strings.Index(spec, "/"); i != -1 {
+		count, period = spec[:i], spec[i+1:]
+		if period != "" && (period[0] < '0' || period[0] > '9') {
+			period = "1" + period
+		}
+	}
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid rate %q: count must be a positive number", spec)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return nil, fmt.Errorf("invalid rate %q: period must be a duration such as s, m or 6h", spec)
+	}
+	burst := int(math.Max(1, math.Floor(n)))
+	return rate.NewLimiter(rate.Limit(n/d.Seconds()), burst), nil
+}
+
+// NewRateLimitedTransport returns a transport that waits for a token from the limiter of
+// the request host before sending it. Hosts without a limiter are not limited.
+func NewRateLimitedTransport(next http.RoundTripper, limiters map[string]*rate.Limiter) http.RoundTripper {
+	if len(limiters) == 0 {
+		return next
+	}
+	return &rateLimitedTransport{next: next, limiters: limiters}
+}
+
+// rateLimitedTransport applies per-host token buckets.
+type rateLimitedTransport struct {
+	next     http.RoundTripper
+	limiters map[string]*rate.Limiter
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if l, ok := t.limiters[req.URL.Host]; ok {
+		if err := l.Wait(req.Context()); err != nil {
+			return nil, fmt.Errorf("waiting for %s rate limit: %w", req.URL.Host, err)
+		}
+	}
+	return t.next.RoundTrip(req)
+}
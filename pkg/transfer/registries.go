@@ -0,0 +1,238 @@
+package transfer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"gopkg.in/yaml.v3"
+)
+
+// RegistryConfig holds the connection settings of one registry.
+type RegistryConfig struct {
+	// Insecure allows plain HTTP and TLS certificates that cannot be verified.
+	Insecure bool `yaml:"insecure"`
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string `yaml:"caFile"`
+	// CertFile and KeyFile are a PEM client certificate and key for mutual TLS.
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// Proxy is an HTTP proxy URL for this registry (default: HTTPS_PROXY and friends).
+	Proxy string `yaml:"proxy"`
+	// Mirrors are tried in order before the registry itself when pulling, as host[/path].
+	Mirrors []string `yaml:"mirrors"`
+}
+
+// Registries maps canonical registry hosts (see RegistryHost) to their settings.
+type Registries map[string]*RegistryConfig
+
+// LoadRegistries reads a registries file of the form
+//
+//	registries:
+//	  docker.io:
+//	    mirrors: [proxy.example.com/dockerhub]
+//	  registry.internal:5000:
+//	    insecure: true
+//
+// Relative certificate paths are resolved against the directory of the file.
+func LoadRegistries(path string) (Registries, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading registries config: %w", err)
+	}
+	var file struct {
+		Registries map[string]*RegistryConfig `yaml:"registries"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing registries config %s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	registries := Registries{}
+	for registry, cfg := range file.Registries {
+		if cfg == nil {
+			continue
+		}
+		entry, err := registries.Entry(registry)
+		if err != nil {
+			return nil, fmt.Errorf("registries config %s: %w", path, err)
+		}
+		cfg.CAFile, cfg.CertFile, cfg.KeyFile = resolve(cfg.CAFile), resolve(cfg.CertFile), resolve(cfg.KeyFile)
+		*entry = *cfg
+	}
+	return registries, nil
+}
+
+// Entry returns the settings of registry, adding empty settings if it has none yet.
+func (r Registries) Entry(registry string) (*RegistryConfig, error) {
+	host, err := RegistryHost(registry)
+	if err != nil {
+		return nil, err
+	}
+	if r[host] == nil {
+		r[host] = &RegistryConfig{}
+	}
+	return r[host], nil
+}
+
+// lookup returns the settings of a canonical registry host, or nil.
+func (r Registries) lookup(host string) *RegistryConfig {
+	if r == nil {
+		return nil
+	}
+	return r[host]
+}
+
+// ParseReference parses an image reference, defaulting to docker.io, and allows plain
+// HTTP for registries configured as insecure.
+func (r Registries) ParseReference(ref string) (name.Reference, error) {
+	ref = normalizeImageReference(ref)
+	parsed, err := name.ParseReference(ref)
+	if err != nil {
+		return nil, fmt.Errorf("parsing reference %s: %w", ref, err)
+	}
+	if cfg := r.lookup(parsed.Context().RegistryStr()); cfg != nil && cfg.Insecure {
+		return name.ParseReference(ref, name.Insecure)
+	}
+	return parsed, nil
+}
+
+// parseRepository parses a repository name, defaulting to docker.io, and allows plain HTTP
+// for registries configured as insecure.
+func (r Registries) parseRepository(repository string) (name.Repository, error) {
+	repository = normalizeImageReference(repository)
+	repo, err := name.NewRepository(repository)
+	if err != nil {
+		return name.Repository{}, fmt.Errorf("parsing repository %s: %w", repository, err)
+	}
+	if cfg := r.lookup(repo.RegistryStr()); cfg != nil && cfg.Insecure {
+		return name.NewRepository(repository, name.Insecure)
+	}
+	return repo, nil
+}
+
+// pullCandidates returns the references to try when pulling ref: those of the configured
+// mirrors in order, followed by ref itself.
+func (r Registries) pullCandidates(ref name.Reference) ([]name.Reference, error) {
+	cfg := r.lookup(ref.Context().RegistryStr())
+	if cfg == nil || len(cfg.Mirrors) == 0 {
+		return []name.Reference{ref}, nil
+	}
+	candidates := make([]name.Reference, 0, len(cfg.Mirrors)+1)
+	for _, mirror := range cfg.Mirrors {
+		mirror = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(mirror, "https://"), "http://"), "/")
+		host, path, _ := strings.Cut(mirror, "/")
+		repository := ref.Context().RepositoryStr()
+		if path != "" {
+			repository = path + "/" + repository
+		}
+		var nameOpts []name.Option
+		if m := r.lookup(host); m != nil && m.Insecure {
+			nameOpts = append(nameOpts, name.Insecure)
+		}
+		repo, err := name.NewRepository(host+"/"+repository, nameOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mirror %s for %s: %w", mirror, ref.Context().RegistryStr(), err)
+		}
+		switch v := ref.(type) {
+		case name.Digest:
+			candidates = append(candidates, repo.Digest(v.DigestStr()))
+		case name.Tag:
+			candidates = append(candidates, repo.Tag(v.TagStr()))
+		}
+	}
+	return append(candidates, ref), nil
+}
+
+// NewRegistryTransport returns remote.DefaultTransport with the TLS and proxy settings of
+// each configured registry applied to requests for that host.
+func NewRegistryTransport(registries Registries) (http.RoundTripper, error) {
+	base, ok := remote.DefaultTransport.(*http.Transport)
+	if !ok {
+		return remote.DefaultTransport, nil
+	}
+	hosts := map[string]http.RoundTripper{}
+	for host, cfg := range registries {
+		if !cfg.Insecure && cfg.CAFile == "" && cfg.CertFile == "" && cfg.KeyFile == "" && cfg.Proxy == "" {
+			continue
+		}
+		t := base.Clone()
+		tlsConfig, err := cfg.tlsConfig(t.TLSClientConfig)
+		if err != nil {
+			return nil, fmt.Errorf("registry %s: %w", host, err)
+		}
+		t.TLSClientConfig = tlsConfig
+		if cfg.Proxy != "" {
+			proxy, err := url.Parse(cfg.Proxy)
+			if err != nil {
+				return nil, fmt.Errorf("registry %s: invalid proxy %q: %w", host, cfg.Proxy, err)
+			}
+			t.Proxy = http.ProxyURL(proxy)
+		}
+		hosts[host] = t
+	}
+	if len(hosts) == 0 {
+		return base, nil
+	}
+	return &registryTransport{hosts: hosts, fallback: base}, nil
+}
+
+// tlsConfig extends base with the CA bundle, client certificate and verification setting.
+func (c *RegistryConfig) tlsConfig(base *tls.Config) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if base != nil {
+		cfg = base.Clone()
+	}
+	cfg.InsecureSkipVerify = c.Insecure
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, fmt.Errorf("client certificate and key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// registryTransport sends requests through the transport configured for their host.
+type registryTransport struct {
+	hosts    map[string]http.RoundTripper
+	fallback http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *registryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt, ok := t.hosts[req.URL.Host]; ok {
+		return rt.RoundTrip(req)
+	}
+	return t.fallback.RoundTrip(req)
+}
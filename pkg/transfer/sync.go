@@ -0,0 +1,112 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// TagState describes whether a mirrored tag still holds what its source tag points to.
+type TagState struct {
+	SourceDigest v1.Hash
+	MirrorDigest v1.Hash
+	// Current is true when the mirror holds the source manifest, or, for a copy filtered
+	// to some platforms, only manifests that are still part of the source index.
+	Current bool
+}
+
+// CheckTag resolves srcRef and the existing mirrorRef and compares their digests. Source
+// settings of opts apply to srcRef and DestinationKeychain to mirrorRef.
+func CheckTag(ctx context.Context, srcRef, mirrorRef string, opts Options) (TagState, error) {
+	srcKeychain := opts.SourceKeychain
+	if srcKeychain == nil {
+		srcKeychain = authn.DefaultKeychain
+	}
+	dstKeychain := opts.DestinationKeychain
+	if dstKeychain == nil {
+		dstKeychain = authn.DefaultKeychain
+	}
+
+	src, err := opts.Registries.ParseReference(srcRef)
+	if err != nil {
+		return TagState{}, err
+	}
+	candidates, err := opts.Registries.pullCandidates(src)
+	if err != nil {
+		return TagState{}, err
+	}
+	puller, err := remote.NewPuller(opts.remoteOptions(ctx, srcKeychain)...)
+	if err != nil {
+		return TagState{}, err
+	}
+	log := opts.Logger
+	if log == nil {
+		log = slog.Default()
+	}
+	srcDesc, _, err := getFirst(ctx, puller, candidates, log)
+	if err != nil {
+		return TagState{}, fmt.Errorf("fetching %s: %w", srcRef, err)
+	}
+
+	dst, err := opts.Registries.ParseReference(mirrorRef)
+	if err != nil {
+		return TagState{}, err
+	}
+	dstDesc, err := remote.Get(dst, opts.remoteOptions(ctx, dstKeychain)...)
+	if err != nil {
+		return TagState{}, fmt.Errorf("fetching %s: %w", mirrorRef, err)
+	}
+
+	state := TagState{SourceDigest: srcDesc.Digest, MirrorDigest: dstDesc.Digest}
+	if srcDesc.Digest == dstDesc.Digest {
+		state.Current = true
+		return state, nil
+	}
+	if !srcDesc.MediaType.IsIndex() {
+		return state, nil
+	}
+
+	// A platform-filtered copy is either one child of the source index or an index of a
+	// subset of its children
+	srcChildren, err := indexChildren(srcDesc)
+	if err != nil {
+		return TagState{}, err
+	}
+	if !dstDesc.MediaType.IsIndex() {
+		state.Current = srcChildren[dstDesc.Digest]
+		return state, nil
+	}
+	dstChildren, err := indexChildren(dstDesc)
+	if err != nil {
+		return TagState{}, err
+	}
+	state.Current = len(dstChildren) > 0
+	for digest := range dstChildren {
+		if !srcChildren[digest] {
+			state.Current = false
+			break
+		}
+	}
+	return state, nil
+}
+
+// indexChildren returns the digests of the manifests listed by an index descriptor.
+func indexChildren(desc *remote.Descriptor) (map[v1.Hash]bool, error) {
+	idx, err := desc.ImageIndex()
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[v1.Hash]bool, len(manifest.Manifests))
+	for _, m := range manifest.Manifests {
+		children[m.Digest] = true
+	}
+	return children, nil
+}
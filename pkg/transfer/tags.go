@@ -0,0 +1,215 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/crane"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// TagFilter selects tags of a repository. Empty fields do not filter.
+type TagFilter struct {
+	// Semver is a version constraint such as ">=1.20 <2"; tags that are not versions are skipped.
+	Semver string
+	// Regex must match the tag.
+	Regex string
+	// SiblingOf keeps versions no newer than this tag with the same pre-release suffix
+	// (e.g. -alpine), i.e. the candidates to roll back to. If it is not a version, no
+	// tags are selected.
+	SiblingOf string
+	// Last keeps only the N newest tags left by the other filters.
+	Last int
+	// MaxAge skips tags whose image was created longer ago than this. Images without a
+	// creation time are kept.
+	MaxAge time.Duration
+}
+
+// IsVersion reports whether a tag is a semantic version such as 1.25 or v1.25.3-alpine.
+func IsVersion(tag string) bool {
+	_, err := semver.NewVersion(tag)
+	return err == nil
+}
+
+// Validate checks the semver constraint and regular expression.
+func (f TagFilter) Validate() error {
+	_, _, err := f.compile()
+	return err
+}
+
+// compile parses the semver constraint and regular expression, either of which may be nil.
+func (f TagFilter) compile() (*semver.Constraints, *regexp.Regexp, error) {
+	var constraint *semver.Constraints
+	var re *regexp.Regexp
+	if f.Semver != "" {
+		c, err := semver.NewConstraint(f.Semver)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid semver constraint %q: %w", f.Semver, err)
+		}
+		constraint = c
+	}
+	if f.Regex != "" {
+		r, err := regexp.Compile(f.Regex)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid tag regex %q: %w", f.Regex, err)
+		}
+		re = r
+	}
+	if f.Last < 0 {
+		return nil, nil, fmt.Errorf("last must not be negative, got: %d", f.Last)
+	}
+	return constraint, re, nil
+}
+
+// ListTags lists the tags of a repository such as nginx or ghcr.io/org/app, using the
+// source settings of opts.
+func ListTags(ctx context.Context, repository string, opts Options) ([]string, error) {
+	repo, err := opts.Registries.parseRepository(repository)
+	if err != nil {
+		return nil, err
+	}
+	keychain := opts.SourceKeychain
+	if keychain == nil {
+		keychain = authn.DefaultKeychain
+	}
+	craneOpts := []crane.Option{crane.WithContext(ctx), crane.WithAuthFromKeychain(keychain)}
+	if opts.Transport != nil {
+		craneOpts = append(craneOpts, crane.WithTransport(opts.Transport))
+	}
+	if repo.Scheme() == "http" {
+		craneOpts = append(craneOpts, crane.Insecure)
+	}
+	tags, err := crane.ListTags(repo.String(), craneOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("listing tags of %s: %w", repository, err)
+	}
+	return tags, nil
+}
+
+// SelectTags returns the tags of repository matching filter, oldest first. Versions are
+// ordered by semantic version and come after other tags, which are ordered by name.
+// Creation times are only fetched from the registry when filter.MaxAge is set, newest
+// first until filter.Last tags are kept.
+func SelectTags(ctx context.Context, repository string, tags []string, filter TagFilter, opts Options) ([]string, error) {
+	constraint, re, err := filter.compile()
+	if err != nil {
+		return nil, err
+	}
+	var sibling *semver.Version
+	if filter.SiblingOf != "" {
+		if sibling, err = semver.NewVersion(filter.SiblingOf); err != nil {
+			return nil, nil
+		}
+	}
+
+	var selected []string
+	for _, tag := range tags {
+		if re != nil && !re.MatchString(tag) {
+			continue
+		}
+		v, verr := semver.NewVersion(tag)
+		if constraint != nil && (verr != nil || !constraint.Check(v)) {
+			continue
+		}
+		if sibling != nil && (verr != nil || v.Prerelease() != sibling.Prerelease() || v.GreaterThan(sibling)) {
+			continue
+		}
+		selected = append(selected, tag)
+	}
+	sort.SliceStable(selected, func(i, j int) bool { return tagLess(selected[i], selected[j]) })
+	if filter.MaxAge <= 0 {
+		if filter.Last > 0 && len(selected) > filter.Last {
+			selected = selected[len(selected)-filter.Last:]
+		}
+		return selected, nil
+	}
+
+	// Age is a filter, so it applies before Last; walk newest first to stop early
+	cutoff := time.Now().Add(-filter.MaxAge)
+	var recent []string
+	for i := len(selected) - 1; i >= 0 && (filter.Last <= 0 || len(recent) < filter.Last); i-- {
+		created, err := imageCreated(ctx, repository+":"+selected[i], opts)
+		if err != nil {
+			return nil, err
+		}
+		if created.IsZero() || created.Unix() <= 0 || created.After(cutoff) {
+			recent = append(recent, selected[i])
+		}
+	}
+	for i, j := 0, len(recent)-1; i < j; i, j = i+1, j-1 {
+		recent[i], recent[j] = recent[j], recent[i]
+	}
+	return recent, nil
+}
+
+// tagLess orders tags that are not versions by name before versions in semantic order.
+func tagLess(a, b string) bool {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	switch {
+	case errA == nil && errB == nil:
+		if va.Equal(vb) {
+			return a < b // e.g. v1.2 and 1.2.0
+		}
+		return va.LessThan(vb)
+	case errA != nil && errB != nil:
+		return a < b
+	default:
+		return errA != nil
+	}
+}
+
+// imageCreated returns the creation time of an image, or of the first image of an index
+// matching opts.Platforms.
+func imageCreated(ctx context.Context, ref string, opts Options) (time.Time, error) {
+	r, err := opts.Registries.ParseReference(ref)
+	if err != nil {
+		return time.Time{}, err
+	}
+	keychain := opts.SourceKeychain
+	if keychain == nil {
+		keychain = authn.DefaultKeychain
+	}
+	desc, err := remote.Get(r, opts.remoteOptions(ctx, keychain)...)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fetching %s: %w", ref, err)
+	}
+
+	var img v1.Image
+	if desc.MediaType.IsIndex() {
+		idx, err := desc.ImageIndex()
+		if err != nil {
+			return time.Time{}, err
+		}
+		manifest, err := idx.IndexManifest()
+		if err != nil {
+			return time.Time{}, err
+		}
+		for _, m := range manifest.Manifests {
+			if !m.MediaType.IsImage() || (len(opts.Platforms) > 0 && (m.Platform == nil || !matchesPlatforms(*m.Platform, opts.Platforms))) {
+				continue
+			}
+			if img, err = idx.Image(m.Digest); err != nil {
+				return time.Time{}, err
+			}
+			break
+		}
+		if img == nil {
+			return time.Time{}, nil
+		}
+	} else if img, err = desc.Image(); err != nil {
+		return time.Time{}, err
+	}
+
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reading config of %s: %w", ref, err)
+	}
+	return cfg.Created.Time, nil
+}
@@ -0,0 +1,83 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Warm pulls an image once, including all layers of every manifest in an index
+// (or only those matching opts.Platforms when given), so that a pull-through cache stores it.
+// Nothing is written locally. Only the source settings of opts are used.
+func Warm(ctx context.Context, ref string, opts Options) error {
+	r, err := opts.Registries.ParseReference(ref)
+	if err != nil {
+		return err
+	}
+	keychain := opts.SourceKeychain
+	if keychain == nil {
+		keychain = authn.DefaultKeychain
+	}
+
+	desc, err := remote.Get(r, opts.remoteOptions(ctx, keychain)...)
+	if err != nil {
+		return fmt.Errorf("fetching %s: %w", ref, err)
+	}
+
+	if !desc.MediaType.IsIndex() {
+		img, err := desc.Image()
+		if err != nil {
+			return err
+		}
+		return drainLayers(img)
+	}
+
+	idx, err := desc.ImageIndex()
+	if err != nil {
+		return err
+	}
+	manifest, err := idx.IndexManifest()
+	if err != nil {
+		return err
+	}
+	for _, m := range manifest.Manifests {
+		if !m.MediaType.IsImage() {
+			continue
+		}
+		if len(opts.Platforms) > 0 && (m.Platform == nil || !matchesPlatforms(*m.Platform, opts.Platforms)) {
+			continue
+		}
+		img, err := idx.Image(m.Digest)
+		if err != nil {
+			return err
+		}
+		if err := drainLayers(img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// drainLayers downloads every layer of img and discards the data.
+func drainLayers(img v1.Image) error {
+	layers, err := img.Layers()
+	if err != nil {
+		return err
+	}
+	for _, layer := range layers {
+		rc, err := layer.Compressed()
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(io.Discard, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
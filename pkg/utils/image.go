@@ -0,0 +1,54 @@
+package utils
+
+import "strings"
+
+// DefaultRegistry is the registry assumed for image references without a registry host.
+const DefaultRegistry = "docker.io"
+
+// ImageReference holds the parsed components of an image reference.
+type ImageReference struct {
+	Registry   string `json:"registry" yaml:"registry"`
+	Repository string `json:"repository" yaml:"repository"`
+	Tag        string `json:"tag,omitempty" yaml:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty" yaml:"digest,omitempty"`
+}
+
+// ParseImageReference splits an image reference into registry, repository, tag and digest.
+// Docker Hub references are normalized to docker.io and the library/ namespace.
+func ParseImageReference(image string) ImageReference {
+	var ref ImageReference
+
+	if at := strings.Index(image, "@"); at != -1 {
+		ref.Digest = image[at+1:]
+		image = image[:at]
+	}
+
+	// The tag is everything after the last ':' that is not part of a registry host:port
+	if idx := strings.LastIndex(image, ":"); idx != -1 && !strings.Contains(image[idx+1:], "/") {
+		ref.Tag = image[idx+1:]
+		image = image[:idx]
+	}
+
+	ref.Registry = DefaultRegistry
+	if parts := strings.SplitN(image, "/", 2); len(parts) == 2 {
+		first := parts[0]
+		if strings.Contains(first, ".") || strings.Contains(first, ":") || first == "localhost" {
+			ref.Registry = first
+			image = parts[1]
+		}
+	}
+	if ref.Registry == "index.docker.io" || ref.Registry == "registry-1.docker.io" {
+		ref.Registry = DefaultRegistry
+	}
+	if ref.Registry == DefaultRegistry && !strings.Contains(image, "/") {
+		image = "library/" + image
+	}
+	ref.Repository = image
+
+	return ref
+}
+
+// Name returns the registry and repository without tag or digest.
+func (r ImageReference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
@@ -1,9 +1,6 @@
 package utils
 
-import (
-	"regexp"
-	"strings"
-)
+import "krane/pkg/match"
 
 // RemoveDuplicates removes duplicate strings from a slice while preserving order.
 func RemoveDuplicates(slice []string) []string {
@@ -19,23 +16,35 @@ func RemoveDuplicates(slice []string) []string {
 	return result
 }
 
-// FilterImages applies include/exclude patterns to filter image names.
+// FilterImages applies include/exclude patterns (see package match) to filter image names.
+// Patterns restricted to a component are matched against the parsed image reference.
 func FilterImages(images []string, includes, excludes []string) ([]string, error) {
 	var result []string
-	incMatchers, err := compileMatchers(includes)
+	incMatchers, err := match.CompileAll(includes)
 	if err != nil {
 		return nil, err
 	}
-	excMatchers, err := compileMatchers(excludes)
+	excMatchers, err := match.CompileAll(excludes)
 	if err != nil {
 		return nil, err
 	}
+	needComponents := match.HasFields(incMatchers) || match.HasFields(excMatchers)
 
 	for _, img := range images {
+		var components map[string]string
+		if needComponents {
+			ref := ParseImageReference(img)
+			components = map[string]string{
+				match.FieldRegistry:   ref.Registry,
+				match.FieldRepository: ref.Repository,
+				match.FieldTag:        ref.Tag,
+			}
+		}
+
 		if len(incMatchers) > 0 {
 			matched := false
 			for _, m := range incMatchers {
-				if m.match(img) {
+				if m.MatchComponents(img, components) {
 					matched = true
 					break
 				}
@@ -47,7 +56,7 @@ func FilterImages(images []string, includes, excludes []string) ([]string, error
 
 		excluded := false
 		for _, m := range excMatchers {
-			if m.match(img) {
+			if m.MatchComponents(img, components) {
 				excluded = true
 				break
 			}
@@ -59,36 +68,3 @@ func FilterImages(images []string, includes, excludes []string) ([]string, error
 	}
 	return result, nil
 }
-
-type matcher struct {
-	isRegex bool
-	prefix  string
-	re      *regexp.Regexp
-}
-
-// match checks if the given string matches this matcher pattern.
-func (m matcher) match(s string) bool {
-	if m.isRegex {
-		return m.re.MatchString(s)
-	}
-	return strings.HasPrefix(s, m.prefix)
-}
-
-// compileMatchers compiles string patterns into regex or prefix matchers.
-func compileMatchers(patterns []string) ([]matcher, error) {
-	var matchers []matcher
-	for _, p := range patterns {
-		p = strings.TrimSpace(p)
-		if p == "" {
-			continue
-		}
-		// Try to compile as regex; if fails, treat as prefix
-		re, err := regexp.Compile(p)
-		if err != nil {
-			matchers = append(matchers, matcher{isRegex: false, prefix: p})
-			continue
-		}
-		matchers = append(matchers, matcher{isRegex: true, re: re})
-	}
-	return matchers, nil
-}
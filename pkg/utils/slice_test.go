@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterImages(t *testing.T) {
+	images := []string{
+		"nginx:1.27",
+		"nginx:latest",
+		"registry.k8s.io/ingress-nginx/controller:v1.12.3",
+		"registry:5000/app:1.0",
+		"ghcr.io/acme/log4j-app:2.14",
+		"quay.io/prometheus/prometheus:v3.0.0",
+	}
+
+	tests := []struct {
+		name     string
+		includes []string
+		excludes []string
+		want     []string
+	}{
+		{
+			name: "no patterns",
+			want: images,
+		},
+		{
+			name:     "include prefix",
+			includes: []string{"nginx"},
+			want:     []string{"nginx:1.27", "nginx:latest"},
+		},
+		{
+			name:     "exclude wins over include",
+			includes: []string{"nginx"},
+			excludes: []string{"tag:latest"},
+			want:     []string{"nginx:1.27"},
+		},
+		{
+			name:     "exclude only",
+			excludes: []string{"glob:*log4j*", "registry:quay.io"},
+			want:     []string{"nginx:1.27", "nginx:latest", "registry.k8s.io/ingress-nginx/controller:v1.12.3", "registry:5000/app:1.0"},
+		},
+		{
+			name:     "any include matches",
+			includes: []string{"registry:exact:registry.k8s.io", "re:prometheus"},
+			want:     []string{"registry.k8s.io/ingress-nginx/controller:v1.12.3", "quay.io/prometheus/prometheus:v3.0.0"},
+		},
+		{
+			name:     "host with port",
+			includes: []string{"registry:5000/"},
+			want:     []string{"registry:5000/app:1.0"},
+		},
+		{
+			name:     "registry field with port",
+			includes: []string{"registry:exact:registry:5000"},
+			want:     []string{"registry:5000/app:1.0"},
+		},
+		{
+			name:     "docker hub images have library repositories and the docker.io registry",
+			includes: []string{"repository:exact:library/nginx"},
+			excludes: []string{"!registry:docker.io"},
+			want:     []string{"nginx:1.27", "nginx:latest"},
+		},
+		{
+			name:     "negated include",
+			includes: []string{"!i:NGINX"},
+			want:     []string{"registry.k8s.io/ingress-nginx/controller:v1.12.3", "registry:5000/app:1.0", "ghcr.io/acme/log4j-app:2.14", "quay.io/prometheus/prometheus:v3.0.0"},
+		},
+		{
+			name:     "nothing left",
+			includes: []string{"nginx"},
+			excludes: []string{"glob:*"},
+			want:     nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FilterImages(images, tt.includes, tt.excludes)
+			if err != nil {
+				t.Fatalf("FilterImages: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterImages(%v, %v) = %v, want %v", tt.includes, tt.excludes, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := FilterImages(images, nil, []string{"re:("}); err == nil {
+		t.Error("FilterImages accepted an invalid exclude pattern")
+	}
+}